@@ -0,0 +1,298 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type ActionStats struct {
+	Queued     int   `json:"queued"`
+	Pending    int64 `json:"pending"`
+	Flushed    int64 `json:"flushed"`
+	Failed     int64 `json:"failed"`
+	Dropped    int64 `json:"dropped"`
+	Spilled    int64 `json:"spilled"`
+	Replayed   int64 `json:"replayed"`
+	SpillBytes int64 `json:"spill_bytes"`
+}
+
+type actionPipelineConfig struct {
+	overflow     string
+	blockTimeout time.Duration
+	spill        *utils.SpillBuffer
+}
+
+var (
+	actionsChan   = make(chan UserAction, consts.ActionsChanelSize)
+	actionsDone   = make(chan struct{})
+	flushInterval = consts.FlushInterval
+	flushSize     = consts.FlushSize
+
+	// appendMutex keeps AppendAction from sending on the channel once shutdown has closed it
+	appendMutex   sync.RWMutex
+	actionsClosed bool
+	pipeline      actionPipelineConfig
+
+	actionsPending  atomic.Int64
+	actionsFlushed  atomic.Int64
+	actionsFailed   atomic.Int64
+	actionsDropped  atomic.Int64
+	actionsSpilled  atomic.Int64
+	actionsReplayed atomic.Int64
+)
+
+// AppendAction queues an action for the audit trail. When the queue is full it is spilled to disk,
+// waits for room or is dropped, according to ACTIONS_OVERFLOW.
+func AppendAction(action UserAction) {
+	appendMutex.RLock()
+	defer appendMutex.RUnlock()
+
+	if actionsClosed {
+		actionsDropped.Add(1)
+		return
+	}
+
+	select {
+	case actionsChan <- action:
+		return
+	default:
+	}
+
+	switch pipeline.overflow {
+	case consts.ActionsOverflowSpill:
+		if err := spillActions([]UserAction{action}); err != nil {
+			actionsDropped.Add(1)
+			log.Warnf("Actions queue is full and spilling failed, dropping action: %v", err)
+		}
+	case consts.ActionsOverflowBlock:
+		timer := time.NewTimer(pipeline.blockTimeout)
+		defer timer.Stop()
+		select {
+		case actionsChan <- action:
+		case <-timer.C:
+			actionsDropped.Add(1)
+			log.Warn("Actions queue stayed full, dropping action")
+		}
+	default:
+		actionsDropped.Add(1)
+		log.Warn("Actions queue is full, dropping action")
+	}
+}
+
+func GetActionStats() ActionStats {
+	stats := ActionStats{
+		Queued:   len(actionsChan),
+		Pending:  actionsPending.Load(),
+		Flushed:  actionsFlushed.Load(),
+		Failed:   actionsFailed.Load(),
+		Dropped:  actionsDropped.Load(),
+		Spilled:  actionsSpilled.Load(),
+		Replayed: actionsReplayed.Load(),
+	}
+	if pipeline.spill != nil {
+		stats.SpillBytes = pipeline.spill.Size()
+	}
+	return stats
+}
+
+// ShutDownRedisClient stops accepting actions and waits for the queued ones to be flushed or spilled.
+func ShutDownRedisClient() {
+	appendMutex.Lock()
+	if actionsClosed {
+		appendMutex.Unlock()
+		return
+	}
+	actionsClosed = true
+	close(actionsChan)
+	appendMutex.Unlock()
+
+	select {
+	case <-actionsDone:
+	case <-time.After(consts.ActionsShutdownTimeout):
+		log.Warnf("Timed out flushing actions, %d still pending", actionsPending.Load())
+	}
+}
+
+func startActionPipeline() {
+	pipeline = loadActionPipelineConfig()
+	go actionWorker()
+}
+
+func loadActionPipelineConfig() actionPipelineConfig {
+	spillDir, _ := utils.GetEnvVar[string]("ACTIONS_SPILL_DIR", "")
+	config := actionPipelineConfig{
+		overflow: consts.ActionsOverflowBlock,
+	}
+	if spillDir != "" {
+		config.overflow = consts.ActionsOverflowSpill
+	}
+	config.overflow, _ = utils.GetEnvVar[string]("ACTIONS_OVERFLOW", config.overflow)
+	config.blockTimeout, _ = utils.GetEnvVar[time.Duration]("ACTIONS_BLOCK_TIMEOUT", consts.DefaultActionsBlockTimeout)
+
+	if spillDir != "" {
+		maxBytes, _ := utils.GetEnvVar[int]("ACTIONS_SPILL_MAX_BYTES", consts.DefaultActionsSpillMaxBytes)
+		spill, err := utils.NewSpillBuffer(spillDir, int64(maxBytes))
+		if err != nil {
+			log.Errorf("Action spill buffer unavailable: %v", err)
+		} else {
+			config.spill = spill
+		}
+	}
+	if config.overflow == consts.ActionsOverflowSpill && config.spill == nil {
+		log.Warn("ACTIONS_OVERFLOW is spill without a usable ACTIONS_SPILL_DIR, applying backpressure instead")
+		config.overflow = consts.ActionsOverflowBlock
+	}
+	return config
+}
+
+// actionWorker is the only reader of actionsChan and the only owner of the buffer,
+// so flushing needs no lock and cannot block on itself.
+func actionWorker() {
+	defer close(actionsDone)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var buffer []UserAction
+	var lastFailure time.Time
+	flush := func() {
+		if len(buffer) == 0 {
+			return
+		}
+		if err := writeActions(buffer); err != nil {
+			lastFailure = time.Now()
+			buffer = retainActions(buffer, err)
+		} else {
+			buffer = nil
+		}
+		actionsPending.Store(int64(len(buffer)))
+	}
+
+	for {
+		select {
+		case action, ok := <-actionsChan:
+			if !ok {
+				flush()
+				if len(buffer) > 0 {
+					actionsDropped.Add(int64(len(buffer)))
+					log.Errorf("Dropping %d actions that could not be flushed before shutdown", len(buffer))
+				}
+				return
+			}
+			buffer = append(buffer, action)
+			actionsPending.Store(int64(len(buffer)))
+			// While Redis is failing, wait for the next tick rather than retrying on every action
+			if len(buffer) >= flushSize && time.Since(lastFailure) >= flushInterval {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			if len(buffer) == 0 {
+				replaySpilledActions()
+			}
+		}
+	}
+}
+
+func writeActions(actions []UserAction) error {
+	if redisClient == nil {
+		return errors.New("redis client not initialized")
+	}
+
+	// Stream IDs follow insertion order, so the tenant stream is written in the order actions happened
+	sorted := append([]UserAction(nil), actions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	ctx := context.Background()
+	retention := getAuditRetention()
+	pipe := redisClient.Pipeline()
+	for _, action := range sorted {
+		if err := appendAuditToPipeline(ctx, pipe, action, retention); err != nil {
+			log.Errorf("Failed to marshal action of %s: %v", action.User, err)
+		}
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	actionsFlushed.Add(int64(len(actions)))
+	return nil
+}
+
+// retainActions keeps a batch Redis rejected: on disk when spilling is configured,
+// otherwise in memory up to consts.MaxPendingActions, dropping the oldest beyond that.
+func retainActions(actions []UserAction, flushErr error) []UserAction {
+	actionsFailed.Add(int64(len(actions)))
+	log.Errorf("Failed to flush %d actions: %v", len(actions), flushErr)
+
+	if pipeline.spill != nil {
+		err := spillActions(actions)
+		if err == nil {
+			return nil
+		}
+		log.Errorf("Failed to spill actions, keeping them in memory: %v", err)
+	}
+
+	if excess := len(actions) - consts.MaxPendingActions; excess > 0 {
+		actionsDropped.Add(int64(excess))
+		log.Errorf("Dropping %d actions beyond the pending limit", excess)
+		actions = actions[excess:]
+	}
+	return actions
+}
+
+func spillActions(actions []UserAction) error {
+	if pipeline.spill == nil {
+		return errors.New("no spill buffer configured")
+	}
+
+	records := make([][]byte, 0, len(actions))
+	for _, action := range actions {
+		data, err := json.Marshal(action)
+		if err != nil {
+			return err
+		}
+		records = append(records, data)
+	}
+	if err := pipeline.spill.Append(records); err != nil {
+		return err
+	}
+	actionsSpilled.Add(int64(len(actions)))
+	return nil
+}
+
+// replaySpilledActions moves spilled actions to Redis once it accepts writes again.
+func replaySpilledActions() {
+	if pipeline.spill == nil || pipeline.spill.Size() == 0 {
+		return
+	}
+
+	replayed, err := pipeline.spill.Drain(flushSize, func(records [][]byte) error {
+		actions := make([]UserAction, 0, len(records))
+		for _, record := range records {
+			var action UserAction
+			if err := json.Unmarshal(record, &action); err != nil {
+				log.Errorf("Discarding unreadable spilled action: %v", err)
+				actionsDropped.Add(1)
+				continue
+			}
+			actions = append(actions, action)
+		}
+		return writeActions(actions)
+	})
+	actionsReplayed.Add(int64(replayed))
+	if err != nil {
+		log.Warnf("Replaying spilled actions stopped after %d: %v", replayed, err)
+	} else if replayed > 0 {
+		log.Infof("Replayed %d spilled actions", replayed)
+	}
+}
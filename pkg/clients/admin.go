@@ -0,0 +1,315 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrIndexNotFound = errors.New("index not found")
+	ErrTaskNotFound  = errors.New("task not found")
+)
+
+type IndexInfo struct {
+	Name            string   `json:"name"`
+	Health          string   `json:"health"`
+	Status          string   `json:"status"`
+	DocsCount       int      `json:"docs_count"`
+	StoreSize       string   `json:"store_size"`
+	Aliases         []string `json:"aliases"`
+	MappingVersion  int      `json:"mapping_version"`
+	ExpectedVersion int      `json:"expected_version,omitempty"`
+	Managed         bool     `json:"managed"`
+}
+
+type ReindexTask struct {
+	TaskID    string `json:"task_id"`
+	Completed bool   `json:"completed"`
+	Total     int    `json:"total"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+	Deleted   int    `json:"deleted"`
+	Progress  int    `json:"progress"`
+	Failures  int    `json:"failures"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ListIndices returns every non-system index with its doc count, aliases and mapping version.
+func ListIndices(ctx context.Context) ([]IndexInfo, error) {
+	if EsClient == nil {
+		return nil, errors.New("elasticsearch client not initialized")
+	}
+
+	var cat []map[string]string
+	res, err := EsClient.Cat.Indices(
+		EsClient.Cat.Indices.WithContext(ctx),
+		EsClient.Cat.Indices.WithFormat("json"),
+		EsClient.Cat.Indices.WithH("index", "health", "status", "docs.count", "store.size"),
+	)
+	if err := decodeResponse(res, err, &cat); err != nil {
+		return nil, fmt.Errorf("cat indices failed: %w", err)
+	}
+
+	aliases, err := getAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := getMappingVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]IndexInfo, 0, len(cat))
+	for _, row := range cat {
+		name := row["index"]
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		docsCount, _ := strconv.Atoi(row["docs.count"])
+		info := IndexInfo{
+			Name:           name,
+			Health:         row["health"],
+			Status:         row["status"],
+			DocsCount:      docsCount,
+			StoreSize:      row["store.size"],
+			Aliases:        aliases[name],
+			MappingVersion: versions[name],
+		}
+		if expected, ok := managedMapping(name, info.Aliases); ok {
+			info.Managed = true
+			info.ExpectedVersion = expected.version
+		}
+		indices = append(indices, info)
+	}
+
+	return indices, nil
+}
+
+// GetIndex returns the summary of a single index.
+func GetIndex(ctx context.Context, index string) (*IndexInfo, error) {
+	indices, err := ListIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range indices {
+		if info.Name == index {
+			return &info, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrIndexNotFound, index)
+}
+
+// StartReindex launches an asynchronous reindex and returns the Elasticsearch task ID tracking it.
+// A missing destination is created with the source's managed mapping when there is one.
+func StartReindex(ctx context.Context, source, dest string) (string, error) {
+	if EsClient == nil {
+		return "", errors.New("elasticsearch client not initialized")
+	}
+
+	if expected, ok := managedMapping(source, nil); ok {
+		if err := createIndex(EsClient, dest, expected.definition); err != nil {
+			return "", err
+		}
+	}
+
+	body := map[string]interface{}{
+		"source": map[string]interface{}{"index": source},
+		"dest":   map[string]interface{}{"index": dest},
+	}
+
+	var started struct {
+		Task string `json:"task"`
+	}
+	res, err := EsClient.Reindex(
+		esutil.NewJSONReader(body),
+		EsClient.Reindex.WithContext(ctx),
+		EsClient.Reindex.WithWaitForCompletion(false),
+	)
+	if err := decodeResponse(res, err, &started); err != nil {
+		return "", fmt.Errorf("reindex request failed: %w", err)
+	}
+
+	log.Infof("Reindex from %s to %s started as task %s", source, dest, started.Task)
+	return started.Task, nil
+}
+
+// GetReindexTask reports the progress of a reindex started by StartReindex.
+func GetReindexTask(ctx context.Context, taskID string) (*ReindexTask, error) {
+	if EsClient == nil {
+		return nil, errors.New("elasticsearch client not initialized")
+	}
+
+	var body struct {
+		Completed bool `json:"completed"`
+		Task      struct {
+			Status struct {
+				Total   int `json:"total"`
+				Created int `json:"created"`
+				Updated int `json:"updated"`
+				Deleted int `json:"deleted"`
+			} `json:"status"`
+		} `json:"task"`
+		Response struct {
+			Failures []interface{} `json:"failures"`
+		} `json:"response"`
+		Error map[string]interface{} `json:"error"`
+	}
+	res, err := EsClient.Tasks.Get(taskID, EsClient.Tasks.Get.WithContext(ctx))
+	if err == nil && res.StatusCode == http.StatusNotFound {
+		// decodeResponse would take the 404 for a missing index
+		res.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	if err := decodeResponse(res, err, &body); err != nil {
+		return nil, fmt.Errorf("get task failed: %w", err)
+	}
+
+	status := body.Task.Status
+	task := &ReindexTask{
+		TaskID:    taskID,
+		Completed: body.Completed,
+		Total:     status.Total,
+		Created:   status.Created,
+		Updated:   status.Updated,
+		Deleted:   status.Deleted,
+		Failures:  len(body.Response.Failures),
+	}
+	if status.Total > 0 {
+		task.Progress = (status.Created + status.Updated + status.Deleted) * 100 / status.Total
+	} else if body.Completed {
+		task.Progress = 100
+	}
+	if reason, ok := body.Error["reason"].(string); ok {
+		task.Error = reason
+	}
+
+	return task, nil
+}
+
+func RefreshIndex(ctx context.Context, index string) error {
+	if EsClient == nil {
+		return errors.New("elasticsearch client not initialized")
+	}
+	return checkResponse(EsClient.Indices.Refresh(
+		EsClient.Indices.Refresh.WithContext(ctx),
+		EsClient.Indices.Refresh.WithIndex(index),
+	))
+}
+
+func FlushIndex(ctx context.Context, index string) error {
+	if EsClient == nil {
+		return errors.New("elasticsearch client not initialized")
+	}
+	return checkResponse(EsClient.Indices.Flush(
+		EsClient.Indices.Flush.WithContext(ctx),
+		EsClient.Indices.Flush.WithIndex(index),
+	))
+}
+
+func CloseIndex(ctx context.Context, index string) error {
+	if EsClient == nil {
+		return errors.New("elasticsearch client not initialized")
+	}
+	return checkResponse(EsClient.Indices.Close([]string{index}, EsClient.Indices.Close.WithContext(ctx)))
+}
+
+func OpenIndex(ctx context.Context, index string) error {
+	if EsClient == nil {
+		return errors.New("elasticsearch client not initialized")
+	}
+	return checkResponse(EsClient.Indices.Open([]string{index}, EsClient.Indices.Open.WithContext(ctx)))
+}
+
+type expectedMapping struct {
+	definition string
+	version    int
+}
+
+// managedMapping finds the mapping this service expects for an index, matching by name or alias.
+func managedMapping(index string, aliases []string) (expectedMapping, bool) {
+	for _, indexMapping := range consts.IndexMappings {
+		name := IndexName(indexMapping.IndexName)
+		if name != index && !lo.Contains(aliases, name) {
+			continue
+		}
+		var definition struct {
+			Mappings map[string]interface{} `json:"mappings"`
+		}
+		if err := json.Unmarshal([]byte(indexMapping.Mapping), &definition); err != nil {
+			return expectedMapping{}, false
+		}
+		return expectedMapping{
+			definition: indexMapping.Mapping,
+			version:    utils.MappingVersion(definition.Mappings),
+		}, true
+	}
+	return expectedMapping{}, false
+}
+
+func getAliases(ctx context.Context) (map[string][]string, error) {
+	var body map[string]struct {
+		Aliases map[string]interface{} `json:"aliases"`
+	}
+	res, err := EsClient.Indices.GetAlias(EsClient.Indices.GetAlias.WithContext(ctx))
+	if err := decodeResponse(res, err, &body); err != nil {
+		return nil, fmt.Errorf("get aliases failed: %w", err)
+	}
+
+	aliases := make(map[string][]string, len(body))
+	for index, indexAliases := range body {
+		aliases[index] = lo.Keys(indexAliases.Aliases)
+	}
+	return aliases, nil
+}
+
+func getMappingVersions(ctx context.Context) (map[string]int, error) {
+	var body map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	res, err := EsClient.Indices.GetMapping(EsClient.Indices.GetMapping.WithContext(ctx))
+	if err := decodeResponse(res, err, &body); err != nil {
+		return nil, fmt.Errorf("get mappings failed: %w", err)
+	}
+
+	versions := make(map[string]int, len(body))
+	for index, indexBody := range body {
+		versions[index] = utils.MappingVersion(indexBody.Mappings)
+	}
+	return versions, nil
+}
+
+func checkResponse(res *esapi.Response, err error) error {
+	return decodeResponse(res, err, nil)
+}
+
+// decodeResponse turns an Elasticsearch response into an error and, when out is not nil, decodes its body.
+func decodeResponse(res *esapi.Response, err error, out interface{}) error {
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return fmt.Errorf("%w: %s", ErrIndexNotFound, res.String())
+	}
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch returned error: %s", res.String())
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,247 @@
+package clients
+
+import (
+	"book_service/pkg/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey is the stored record of a managed API key. Only the hash of the key itself is kept.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Hash       string     `json:"hash"`
+	Prefix     string     `json:"prefix"`
+	Owner      string     `json:"owner"`
+	Roles      []string   `json:"roles"`
+	Scopes     []string   `json:"scopes"`
+	Tenant     string     `json:"tenant,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+func (k APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// CreateAPIKey stores a new key and returns its record along with the raw key, which is never shown again.
+func CreateAPIKey(ctx context.Context, owner, tenant string, roles, scopes []string, expiresAt *time.Time) (APIKey, string, error) {
+	if redisClient == nil {
+		return APIKey{}, "", errors.New("redis client not initialized")
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	apiKey := APIKey{
+		ID:        uuid.NewString(),
+		Hash:      utils.HashAPIKey(rawKey),
+		Prefix:    rawKey[:apiKeyPrefixLength],
+		Owner:     owner,
+		Roles:     roles,
+		Scopes:    scopes,
+		Tenant:    tenant,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+	if err := saveAPIKey(ctx, apiKey, ""); err != nil {
+		return APIKey{}, "", err
+	}
+	return apiKey, rawKey, nil
+}
+
+func ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	if redisClient == nil {
+		return nil, errors.New("redis client not initialized")
+	}
+
+	ids, err := redisClient.SMembers(ctx, apiKeysKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing api keys: %w", err)
+	}
+
+	keys := make([]APIKey, 0, len(ids))
+	for _, id := range ids {
+		apiKey, err := GetAPIKey(ctx, id)
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, apiKey)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func GetAPIKey(ctx context.Context, id string) (APIKey, error) {
+	if redisClient == nil {
+		return APIKey{}, errors.New("redis client not initialized")
+	}
+
+	values, err := redisClient.MGet(ctx, apiKeyRecordKey(id), apiKeyLastUsedKey(id)).Result()
+	if err != nil {
+		return APIKey{}, fmt.Errorf("reading api key %s: %w", id, err)
+	}
+	record, ok := values[0].(string)
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+
+	var apiKey APIKey
+	if err := json.Unmarshal([]byte(record), &apiKey); err != nil {
+		return APIKey{}, fmt.Errorf("decoding api key %s: %w", id, err)
+	}
+	if lastUsed, ok := values[1].(string); ok {
+		if usedAt, err := time.Parse(time.RFC3339, lastUsed); err == nil {
+			apiKey.LastUsedAt = &usedAt
+		}
+	}
+	return apiKey, nil
+}
+
+// LookupAPIKey finds the record of a key by its hash. Expired keys are reported as not found.
+func LookupAPIKey(ctx context.Context, hash string) (APIKey, error) {
+	if redisClient == nil {
+		return APIKey{}, errors.New("redis client not initialized")
+	}
+
+	id, err := redisClient.Get(ctx, apiKeyHashKey(hash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return APIKey{}, fmt.Errorf("looking up api key: %w", err)
+	}
+
+	apiKey, err := GetAPIKey(ctx, id)
+	if err != nil {
+		return APIKey{}, err
+	}
+	if apiKey.Hash != hash || apiKey.Expired(time.Now()) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return apiKey, nil
+}
+
+// RotateAPIKey replaces the secret of a key, keeping its id, owner, roles, scopes and tenant.
+// The previous key stops working immediately.
+func RotateAPIKey(ctx context.Context, id string) (APIKey, string, error) {
+	apiKey, err := GetAPIKey(ctx, id)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	previousHash := apiKey.Hash
+	apiKey.Hash = utils.HashAPIKey(rawKey)
+	apiKey.Prefix = rawKey[:apiKeyPrefixLength]
+	apiKey.LastUsedAt = nil
+	if err := saveAPIKey(ctx, apiKey, previousHash); err != nil {
+		return APIKey{}, "", err
+	}
+	return apiKey, rawKey, nil
+}
+
+func RevokeAPIKey(ctx context.Context, id string) (APIKey, error) {
+	apiKey, err := GetAPIKey(ctx, id)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	pipe := redisClient.TxPipeline()
+	pipe.Del(ctx, apiKeyRecordKey(id), apiKeyLastUsedKey(id), apiKeyHashKey(apiKey.Hash))
+	pipe.SRem(ctx, apiKeysKey(), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return APIKey{}, fmt.Errorf("revoking api key %s: %w", id, err)
+	}
+	return apiKey, nil
+}
+
+// touchAPIKeyScript records the last use of a key only while its hash still resolves to it,
+// so a use recorded after a revoke or a rotation leaves nothing behind.
+var touchAPIKeyScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[2], ARGV[2])
+end
+return 0
+`)
+
+// TouchAPIKey records the last use of the key with the given hash. It is kept apart from the record
+// so it never races with a rotation rewriting the record.
+func TouchAPIKey(ctx context.Context, id, hash string, usedAt time.Time) error {
+	if redisClient == nil {
+		return errors.New("redis client not initialized")
+	}
+	return touchAPIKeyScript.Run(ctx, redisClient, []string{apiKeyHashKey(hash), apiKeyLastUsedKey(id)},
+		id, usedAt.UTC().Format(time.RFC3339),
+	).Err()
+}
+
+const (
+	apiKeyPrefix       = "bk_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 6
+)
+
+func apiKeyRecordKey(id string) string {
+	return redisKey("apikey:" + id)
+}
+
+func apiKeyLastUsedKey(id string) string {
+	return redisKey("apikey:" + id + ":last_used")
+}
+
+func apiKeyHashKey(hash string) string {
+	return redisKey("apikey:hash:" + hash)
+}
+
+func saveAPIKey(ctx context.Context, apiKey APIKey, previousHash string) error {
+	// Last use is stored under its own key
+	apiKey.LastUsedAt = nil
+	data, err := json.Marshal(apiKey)
+	if err != nil {
+		return err
+	}
+
+	pipe := redisClient.TxPipeline()
+	if previousHash != "" {
+		pipe.Del(ctx, apiKeyHashKey(previousHash), apiKeyLastUsedKey(apiKey.ID))
+	}
+	pipe.Set(ctx, apiKeyRecordKey(apiKey.ID), data, 0)
+	pipe.Set(ctx, apiKeyHashKey(apiKey.Hash), apiKey.ID, 0)
+	pipe.SAdd(ctx, apiKeysKey(), apiKey.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("saving api key %s: %w", apiKey.ID, err)
+	}
+	return nil
+}
+
+func generateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generating api key: %w", err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(secret), nil
+}
+
+func apiKeysKey() string {
+	return redisKey("apikeys")
+}
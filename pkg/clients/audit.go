@@ -0,0 +1,219 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+// ActionFilter selects audit entries. Zero values match everything; Path matches as a prefix.
+type ActionFilter struct {
+	User   string
+	Method string
+	Path   string
+	BookID string
+	From   time.Time
+	To     time.Time
+}
+
+func (f ActionFilter) Matches(action UserAction) bool {
+	if f.User != "" && action.User != f.User {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(action.Method, f.Method) {
+		return false
+	}
+	if f.Path != "" && !strings.HasPrefix(action.Path, f.Path) {
+		return false
+	}
+	if f.BookID != "" && action.BookID != f.BookID {
+		return false
+	}
+	if !f.From.IsZero() && action.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && action.Time.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// ActionPage is a page of audit entries, newest first. NextCursor is empty on the last page.
+type ActionPage struct {
+	Actions    []UserAction `json:"actions"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// QueryActions reads the tenant's audit trail newest first, starting below cursor.
+// Filtering by user reads that user's own stream; other filters are applied while scanning,
+// and a page stops early with a cursor once consts.ActivityScanLimit entries have been read.
+func QueryActions(ctx context.Context, tenant string, filter ActionFilter, cursor string, limit int) (ActionPage, error) {
+	if redisClient == nil {
+		return ActionPage{}, errors.New("redis client not initialized")
+	}
+
+	stream := auditStreamKey(tenant)
+	if filter.User != "" {
+		stream = userAuditStreamKey(tenant, filter.User)
+	}
+
+	end := "+"
+	if cursor != "" {
+		end = "(" + cursor
+	}
+	start := auditRangeStart(filter)
+
+	page := ActionPage{Actions: []UserAction{}}
+	batchSize := int64(limit * 2)
+	scanned := 0
+	for {
+		messages, err := redisClient.XRevRangeN(ctx, stream, end, start, batchSize).Result()
+		if err != nil {
+			return ActionPage{}, fmt.Errorf("reading audit trail: %w", err)
+		}
+
+		for _, message := range messages {
+			scanned++
+			action, err := decodeAuditEntry(message)
+			if err != nil {
+				log.Warnf("Skipping audit entry %s: %v", message.ID, err)
+			} else if filter.Matches(action) {
+				page.Actions = append(page.Actions, action)
+			}
+			if len(page.Actions) == limit || scanned >= consts.ActivityScanLimit {
+				page.NextCursor = message.ID
+				return page, nil
+			}
+		}
+
+		if int64(len(messages)) < batchSize {
+			return page, nil
+		}
+		end = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// ScanActions calls visit for every action matching filter, oldest first, until visit returns an error.
+func ScanActions(ctx context.Context, tenant string, filter ActionFilter, visit func(UserAction) error) error {
+	if redisClient == nil {
+		return errors.New("redis client not initialized")
+	}
+
+	stream := auditStreamKey(tenant)
+	if filter.User != "" {
+		stream = userAuditStreamKey(tenant, filter.User)
+	}
+	start, end := auditRangeStart(filter), "+"
+
+	for {
+		messages, err := redisClient.XRangeN(ctx, stream, start, end, auditScanBatch).Result()
+		if err != nil {
+			return fmt.Errorf("reading audit trail: %w", err)
+		}
+
+		for _, message := range messages {
+			action, err := decodeAuditEntry(message)
+			if err != nil {
+				log.Warnf("Skipping audit entry %s: %v", message.ID, err)
+				continue
+			}
+			if !filter.Matches(action) {
+				continue
+			}
+			if err := visit(action); err != nil {
+				return err
+			}
+		}
+
+		if len(messages) < auditScanBatch {
+			return nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// auditRangeStart is the first stream ID an entry matching filter can have. Entries get their stream ID
+// when flushed, which is never before the action but may be long after it when the action waited out
+// a Redis outage in memory or in the spill buffer, so only the start of the window bounds the range
+// and To is left to filter.Matches.
+func auditRangeStart(filter ActionFilter) string {
+	if filter.From.IsZero() {
+		return "-"
+	}
+	return strconv.FormatInt(filter.From.UnixMilli(), 10)
+}
+
+// appendAuditToPipeline adds an action to the tenant's audit stream and to its user's stream,
+// trimming both to the configured retention.
+func appendAuditToPipeline(ctx context.Context, pipe redis.Pipeliner, action UserAction, retention auditRetention) error {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+
+	minID := strconv.FormatInt(time.Now().Add(-retention.age).UnixMilli(), 10)
+	userStream := userAuditStreamKey(action.Tenant, action.User)
+	for _, stream := range []string{auditStreamKey(action.Tenant), userStream} {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: stream,
+			MaxLen: retention.count,
+			Approx: true,
+			Values: map[string]interface{}{auditField: data},
+		})
+		pipe.XTrimMinIDApprox(ctx, stream, minID, 0)
+	}
+	// A user stream nobody writes to anymore is never trimmed, so it expires as a whole
+	pipe.Expire(ctx, userStream, retention.age)
+	return nil
+}
+
+type auditRetention struct {
+	count int64
+	age   time.Duration
+}
+
+func getAuditRetention() auditRetention {
+	count, _ := utils.GetEnvVar[int]("AUDIT_RETENTION_COUNT", consts.AuditRetentionCount)
+	age, _ := utils.GetEnvVar[time.Duration]("AUDIT_RETENTION_AGE", consts.AuditRetentionAge)
+	return auditRetention{count: int64(count), age: age}
+}
+
+const (
+	auditField     = "action"
+	auditScanBatch = 500
+)
+
+func decodeAuditEntry(message redis.XMessage) (UserAction, error) {
+	data, ok := message.Values[auditField].(string)
+	if !ok {
+		return UserAction{}, errors.New("missing action field")
+	}
+
+	var action UserAction
+	if err := json.Unmarshal([]byte(data), &action); err != nil {
+		return UserAction{}, err
+	}
+	action.ID = message.ID
+	return action, nil
+}
+
+// auditStreamKey scopes the audit trail to its tenant; the shared catalogue uses the bare key.
+func auditStreamKey(tenant string) string {
+	if tenant == "" {
+		return redisKey("audit")
+	}
+	return redisKey("tenant:" + tenant + ":audit")
+}
+
+func userAuditStreamKey(tenant, user string) string {
+	return auditStreamKey(tenant) + ":user:" + user
+}
@@ -0,0 +1,176 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+type CacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Shared  int64 `json:"shared"`
+	Errors  int64 `json:"errors"`
+	Evicted int64 `json:"evicted"`
+}
+
+type cacheCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+var (
+	cacheHits    atomic.Int64
+	cacheMisses  atomic.Int64
+	cacheShared  atomic.Int64
+	cacheErrors  atomic.Int64
+	cacheEvicted atomic.Int64
+
+	inflightMutex sync.Mutex
+	inflight      = make(map[string]*cacheCall)
+)
+
+// CacheGetOrLoad returns the cached value of key, or loads it, caches it for ttl and returns it.
+// Concurrent misses on the same key in this process share a single load.
+// The returned bool reports a cache hit. Without Redis the cache is bypassed.
+func CacheGetOrLoad[T any](ctx context.Context, key string, ttl time.Duration, load func() (T, error)) (T, bool, error) {
+	var value T
+
+	if !cacheEnabled() {
+		value, err := load()
+		return value, false, err
+	}
+
+	cached, err := redisClient.Get(ctx, key).Bytes()
+	if err == nil {
+		if err := json.Unmarshal(cached, &value); err == nil {
+			cacheHits.Add(1)
+			return value, true, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		cacheErrors.Add(1)
+		log.Warnf("Cache read of %s failed: %v", key, err)
+	}
+	cacheMisses.Add(1)
+
+	data, err := loadOnce(key, func() ([]byte, error) {
+		loaded, err := load()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+		if err := redisClient.Set(context.Background(), key, data, ttl).Err(); err != nil {
+			cacheErrors.Add(1)
+			log.Warnf("Cache write of %s failed: %v", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return value, false, err
+	}
+
+	err = json.Unmarshal(data, &value)
+	return value, false, err
+}
+
+// InvalidateBookCache drops the cached book and every cached statistic of the tenant.
+// It runs again once Elasticsearch has refreshed, so a read racing the write cannot keep a stale copy.
+func InvalidateBookCache(tenant, id string) {
+	if !cacheEnabled() {
+		return
+	}
+	invalidate := func() {
+		ctx := context.Background()
+		pipe := redisClient.TxPipeline()
+		pipe.Del(ctx, BookCacheKey(tenant, id))
+		pipe.Incr(ctx, statsGenerationKey(tenant))
+		if _, err := pipe.Exec(ctx); err != nil {
+			cacheErrors.Add(1)
+			log.Warnf("Cache invalidation of book %s failed: %v", id, err)
+			return
+		}
+		cacheEvicted.Add(1)
+	}
+	invalidate()
+	time.AfterFunc(consts.CacheRefreshDelay, invalidate)
+}
+
+func BookCacheKey(tenant, id string) string {
+	return redisKey("cache:" + tenant + ":book:" + id)
+}
+
+// StatsCacheKey derives the cache key of a statistics request from its parameters and the tenant's
+// current stats generation, which every write bumps.
+func StatsCacheKey(ctx context.Context, tenant, name string, params interface{}) string {
+	generation := "0"
+	if cacheEnabled() {
+		if value, err := redisClient.Get(ctx, statsGenerationKey(tenant)).Result(); err == nil {
+			generation = value
+		}
+	}
+
+	data, _ := json.Marshal(params)
+	sum := sha1.Sum(data)
+	return redisKey("cache:" + tenant + ":stats:" + name + ":" + generation + ":" + hex.EncodeToString(sum[:]))
+}
+
+func GetCacheStats() CacheStats {
+	return CacheStats{
+		Hits:    cacheHits.Load(),
+		Misses:  cacheMisses.Load(),
+		Shared:  cacheShared.Load(),
+		Errors:  cacheErrors.Load(),
+		Evicted: cacheEvicted.Load(),
+	}
+}
+
+func CacheTTL(name string, fallback time.Duration) time.Duration {
+	ttl, _ := utils.GetEnvVar[time.Duration]("CACHE_"+name+"_TTL", fallback)
+	return ttl
+}
+
+func cacheEnabled() bool {
+	enabled, _ := utils.GetEnvVar[bool]("CACHE_ENABLED", true)
+	return enabled && redisClient != nil
+}
+
+func statsGenerationKey(tenant string) string {
+	return redisKey("cache:" + tenant + ":stats:generation")
+}
+
+// loadOnce runs load for the first caller of a key and hands its result to callers arriving meanwhile.
+func loadOnce(key string, load func() ([]byte, error)) ([]byte, error) {
+	inflightMutex.Lock()
+	if call, ok := inflight[key]; ok {
+		inflightMutex.Unlock()
+		cacheShared.Add(1)
+		<-call.done
+		return call.value, call.err
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	inflight[key] = call
+	inflightMutex.Unlock()
+
+	call.value, call.err = load()
+	close(call.done)
+
+	inflightMutex.Lock()
+	delete(inflight, key)
+	inflightMutex.Unlock()
+
+	return call.value, call.err
+}
@@ -0,0 +1,142 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BookChange is an outbox entry as seen by change stream clients; ID is its stream ID.
+type BookChange struct {
+	ID    string
+	Event BookEvent
+}
+
+// ChangeFilter narrows the change stream of a tenant to books by an author (case insensitive)
+// or within a price range. An update matches when the book matched before or after it,
+// so clients also learn about books leaving their view.
+type ChangeFilter struct {
+	Tenant     string
+	AuthorName string
+	MinPrice   float64
+	MaxPrice   float64
+}
+
+var (
+	changeStreamsClosed = make(chan struct{})
+	closeChangeStreams  sync.Once
+)
+
+func (f ChangeFilter) Matches(event BookEvent) bool {
+	if event.Tenant != f.Tenant {
+		return false
+	}
+	return f.matchesDocument(event.Before) || f.matchesDocument(event.After)
+}
+
+func (f ChangeFilter) matchesDocument(document map[string]interface{}) bool {
+	if document == nil {
+		return false
+	}
+	if f.AuthorName != "" {
+		author, _ := document["author_name"].(string)
+		if !strings.EqualFold(author, f.AuthorName) {
+			return false
+		}
+	}
+	if f.MinPrice > 0 || f.MaxPrice > 0 {
+		price, ok := document["price"].(float64)
+		if !ok || price < f.MinPrice || (f.MaxPrice > 0 && price > f.MaxPrice) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadChanges returns up to count outbox entries after the stream ID after, oldest first.
+func ReadChanges(ctx context.Context, after string, count int64) ([]BookChange, error) {
+	if redisClient == nil {
+		return nil, errors.New("redis client not initialized")
+	}
+
+	messages, err := redisClient.XRangeN(ctx, outboxStream(), "("+after, "+", count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading changes: %w", err)
+	}
+
+	changes := make([]BookChange, 0, len(messages))
+	for _, message := range messages {
+		event, err := decodeOutboxEntry(message)
+		if err != nil {
+			log.Errorf("Skipping outbox entry %s: %v", message.ID, err)
+			continue
+		}
+		changes = append(changes, BookChange{ID: message.ID, Event: event})
+	}
+	return changes, nil
+}
+
+// LatestChangeID is where a client without a Last-Event-ID starts, so it only sees new changes.
+func LatestChangeID(ctx context.Context) (string, error) {
+	if redisClient == nil {
+		return "", errors.New("redis client not initialized")
+	}
+
+	lastID, err := lastOutboxID(ctx)
+	if err != nil || lastID != "" {
+		return lastID, err
+	}
+	return "0-0", nil
+}
+
+// ChangesMissed reports whether entries after the stream ID after may have been trimmed already,
+// in which case a resuming client has to reload rather than replay.
+func ChangesMissed(ctx context.Context, after string) (bool, error) {
+	if redisClient == nil {
+		return false, errors.New("redis client not initialized")
+	}
+
+	first, err := redisClient.XRangeN(ctx, outboxStream(), "-", "+", 1).Result()
+	if err != nil {
+		return false, fmt.Errorf("reading changes: %w", err)
+	}
+	if len(first) == 0 {
+		lastID, err := lastGeneratedOutboxID(ctx)
+		return err == nil && utils.CompareStreamIDs(after, lastID) < 0, err
+	}
+	return utils.CompareStreamIDs(after, first[0].ID) < 0, nil
+}
+
+// CloseChangeStreams ends the open change streams, which would otherwise hold off the server shutdown.
+func CloseChangeStreams() {
+	closeChangeStreams.Do(func() { close(changeStreamsClosed) })
+}
+
+func ChangeStreamsClosed() <-chan struct{} {
+	return changeStreamsClosed
+}
+
+// changesRetentionID is the oldest stream ID trimming keeps for clients to resume from.
+func changesRetentionID() string {
+	retention, _ := utils.GetEnvVar[time.Duration]("CHANGES_RETENTION", consts.DefaultChangesRetention)
+	return fmt.Sprintf("%d-0", time.Now().Add(-retention).UnixMilli())
+}
+
+// lastGeneratedOutboxID is the ID of the newest entry ever added, even when the stream was trimmed empty.
+func lastGeneratedOutboxID(ctx context.Context) (string, error) {
+	info, err := redisClient.XInfoStream(ctx, outboxStream()).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return "0-0", nil
+		}
+		return "", fmt.Errorf("reading outbox: %w", err)
+	}
+	return info.LastGeneratedID, nil
+}
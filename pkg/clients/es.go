@@ -2,12 +2,14 @@ package clients
 
 import (
 	"book_service/pkg/consts"
+	"book_service/pkg/models/common"
 	"book_service/pkg/utils"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"reflect"
@@ -21,22 +23,27 @@ import (
 	"github.com/elastic/go-elasticsearch/v7/esutil"
 )
 
-var booksIndex, _ = utils.GetEnvVar[string]("BOOKS_INDEX", "books")
-
 type IndexRequest struct {
 	Ctx          context.Context
+	Tenant       string
 	Index        string
 	ID           string
 	Document     interface{}
+	Actor        string
+	RequestID    string
 	ResponseChan chan *IndexResult
 	consts.Function
 }
 
 type IndexResult struct {
 	Response *esapi.Response
+	Version  int64
 	Err      error
 }
 
+// errVersionConflict means a conditional write found the document changed since it was read
+var errVersionConflict = errors.New("document changed concurrently")
+
 var (
 	EsClient       *elasticsearch.Client
 	taskQueueIndex chan IndexRequest
@@ -81,7 +88,9 @@ func InitElasticsearchClient() error {
 	}
 
 	EsClient = client
-	InitializeIndices()
+	if err := InitializeIndices(); err != nil {
+		return err
+	}
 	log.Info("Setup indexes successfully")
 	log.Info("Elasticsearch client initialized successfully")
 	return nil
@@ -108,17 +117,33 @@ func ShutdownWorkerPool(numWorkers int) {
 }
 
 func EnqueueIndexTask(ctx context.Context, id string, document interface{}, function consts.Function) {
+	booksIndex := booksIndexFor(ctx)
 	responseChan := make(chan *IndexResult, 1)
 	req := IndexRequest{
 		Ctx:          ctx,
+		Tenant:       TenantFromContext(ctx),
 		Index:        booksIndex,
 		ID:           id,
 		Document:     document,
+		Actor:        actorFromContext(ctx),
+		RequestID:    requestIDFromContext(ctx),
 		ResponseChan: responseChan,
 		Function:     function,
 	}
+	enqueueIndexRequest(req)
+}
+
+func enqueueIndexRequest(req IndexRequest) {
 	taskQueueIndex <- req
-	log.Infof("Task enqueued for %s in index %s", function, booksIndex)
+	log.Infof("Task enqueued for %d in index %s", req.Function, req.Index)
+}
+
+type SearchResult struct {
+	Hits []map[string]interface{}
+	// IDs holds the document ID of each hit
+	IDs          []string
+	Total        int
+	Aggregations map[string]interface{}
 }
 
 func SearchIndex(
@@ -127,13 +152,27 @@ func SearchIndex(
 	size, from int,
 	options ...func(*esapi.SearchRequest),
 ) ([]map[string]interface{}, map[string]interface{}, error) {
+	result, err := Search(ctx, query, size, from, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Hits, result.Aggregations, nil
+}
+
+// Search is SearchIndex with the total hit count, which is exact when WithTrackTotalHits(true) is given.
+func Search(
+	ctx context.Context,
+	query interface{},
+	size, from int,
+	options ...func(*esapi.SearchRequest),
+) (*SearchResult, error) {
 	if EsClient == nil {
-		return nil, nil, errors.New("elasticsearch client not initialized")
+		return nil, errors.New("elasticsearch client not initialized")
 	}
 
 	defaultOptions := []func(*esapi.SearchRequest){
 		EsClient.Search.WithContext(ctx),
-		EsClient.Search.WithIndex(booksIndex),
+		EsClient.Search.WithIndex(booksIndexFor(ctx)),
 		EsClient.Search.WithBody(esutil.NewJSONReader(query)),
 		EsClient.Search.WithSize(size),
 		EsClient.Search.WithFrom(from),
@@ -142,51 +181,156 @@ func SearchIndex(
 
 	res, err := EsClient.Search(allOptions...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("search request failed: %w", err)
+		return nil, fmt.Errorf("search request failed: %w", err)
 	}
+	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, nil, fmt.Errorf("search returned error: %s", res.String())
+		return nil, fmt.Errorf("search returned error: %s", res.String())
 	}
 
 	var r map[string]interface{}
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
 		log.Fatalf("Error parsing response body: %v", err)
-		return nil, nil, err
+		return nil, err
 	}
 
-	hitsArray, ok := r["hits"].(map[string]interface{})["hits"].([]interface{})
+	hitsObject, _ := r["hits"].(map[string]interface{})
+	hitsArray, ok := hitsObject["hits"].([]interface{})
 	if !ok {
 		log.Fatalf("Error: Unable to extract hits from response")
-		return nil, nil, fmt.Errorf("unable to extract hits from response")
+		return nil, fmt.Errorf("unable to extract hits from response")
 	}
 
 	hits := make([]map[string]interface{}, 0, len(hitsArray))
+	ids := make([]string, 0, len(hitsArray))
 	for _, hit := range hitsArray {
 		if hitMap, ok := hit.(map[string]interface{}); ok {
 			if source, ok := hitMap["_source"].(map[string]interface{}); ok {
 				hits = append(hits, source)
+				id, _ := hitMap["_id"].(string)
+				ids = append(ids, id)
 			}
 		}
 	}
 
+	total := 0
+	if totalObject, ok := hitsObject["total"].(map[string]interface{}); ok {
+		if value, ok := totalObject["value"].(float64); ok {
+			total = int(value)
+		}
+	}
+
 	aggregations, ok := r["aggregations"].(map[string]interface{})
 	if !ok {
 		aggregations = nil
 	}
 
-	return hits, aggregations, nil
+	return &SearchResult{Hits: hits, IDs: ids, Total: total, Aggregations: aggregations}, nil
+}
+
+// ScanBooks hands every book matching query to fn with its ID, paging through them with a scroll.
+// Only the given source fields are read; scanning stops at the first error fn returns.
+func ScanBooks(ctx context.Context, query interface{}, fields []string, fn func(id string, book map[string]interface{}) error) error {
+	if EsClient == nil {
+		return errors.New("elasticsearch client not initialized")
+	}
+
+	res, err := EsClient.Search(
+		EsClient.Search.WithContext(ctx),
+		EsClient.Search.WithIndex(booksIndexFor(ctx)),
+		EsClient.Search.WithBody(esutil.NewJSONReader(query)),
+		EsClient.Search.WithSize(consts.ScanBatchSize),
+		EsClient.Search.WithSort("_doc"),
+		EsClient.Search.WithSourceIncludes(fields...),
+		EsClient.Search.WithScroll(consts.ScanKeepAlive),
+	)
+	scrollID := ""
+	defer func() {
+		if scrollID != "" {
+			clearScroll(scrollID)
+		}
+	}()
+
+	for {
+		var page struct {
+			ScrollID string `json:"_scroll_id"`
+			Hits     struct {
+				Hits []struct {
+					ID     string                 `json:"_id"`
+					Source map[string]interface{} `json:"_source"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := decodeResponse(res, err, &page); err != nil {
+			return fmt.Errorf("scanning books: %w", err)
+		}
+		scrollID = page.ScrollID
+		if len(page.Hits.Hits) == 0 {
+			return nil
+		}
+		for _, hit := range page.Hits.Hits {
+			if err := fn(hit.ID, hit.Source); err != nil {
+				return err
+			}
+		}
+
+		res, err = EsClient.Scroll(
+			EsClient.Scroll.WithContext(ctx),
+			EsClient.Scroll.WithScrollID(scrollID),
+			EsClient.Scroll.WithScroll(consts.ScanKeepAlive),
+		)
+	}
+}
+
+// clearScroll frees a scroll early instead of leaving it open until it expires.
+func clearScroll(scrollID string) {
+	res, err := EsClient.ClearScroll(EsClient.ClearScroll.WithScrollID(scrollID))
+	if err != nil {
+		log.Warnf("Failed to clear scroll: %v", err)
+		return
+	}
+	res.Body.Close()
 }
 
-func InitializeIndices() {
+// InitializeIndices creates missing indices and verifies the mapping of existing ones.
+// Incompatible drift is only fatal when STRICT_INDEX_MAPPING is set.
+func InitializeIndices() error {
+	strict, _ := utils.GetEnvVar[bool]("STRICT_INDEX_MAPPING", false)
+
 	for _, indexMapping := range consts.IndexMappings {
-		err := createIndex(EsClient, indexMapping.IndexName, indexMapping.Mapping)
+		index := IndexName(indexMapping.IndexName)
+		if _, err := utils.ParseMapping(indexMapping.Mapping); err != nil {
+			return fmt.Errorf("mapping for index %s is invalid: %w", index, err)
+		}
+
+		err := createIndex(EsClient, index, indexMapping.Mapping)
+		if err != nil {
+			log.Errorf("Failed to create index %s: %v", index, err)
+			continue
+		}
+
+		drifts, err := checkIndexMapping(context.Background(), index, indexMapping.Mapping)
 		if err != nil {
-			log.Errorf("Failed to create index %s: %v", indexMapping.IndexName, err)
-		} else {
-			log.Infof("Index %s initialized successfully", indexMapping.IndexName)
+			log.Errorf("Failed to verify mapping of index %s: %v", index, err)
+			continue
 		}
+		if hasMissingFields(drifts) && !utils.HasIncompatibleDrift(drifts) {
+			if err := addMissingFields(context.Background(), index, indexMapping.Mapping); err != nil {
+				log.Errorf("Failed to add missing fields to index %s: %v", index, err)
+			} else if drifts, err = checkIndexMapping(context.Background(), index, indexMapping.Mapping); err != nil {
+				log.Errorf("Failed to verify mapping of index %s: %v", index, err)
+				continue
+			}
+		}
+		logDrifts(index, drifts)
+
+		if strict && utils.HasIncompatibleDrift(drifts) {
+			return fmt.Errorf("%w: index %s", ErrIncompatibleMapping, index)
+		}
+		log.Infof("Index %s initialized successfully", index)
 	}
+	return nil
 }
 
 func createIndex(client *elasticsearch.Client, index string, mapping string) error {
@@ -221,92 +365,367 @@ func createIndex(client *elasticsearch.Client, index string, mapping string) err
 func indexWorker(tasks <-chan IndexRequest, done chan<- struct{}) {
 	defer func() { done <- struct{}{} }()
 	for req := range tasks {
-		var (
-			res *esapi.Response
-			err error
-		)
-
-		switch req.Function {
-		case consts.DoCreateIndex:
-			res, err = addToIndex(req.Ctx, req.Index, req.ID, req.Document)
-		case consts.DoUpdateIndex:
-			err = updateIndex(req.Index, req.ID, req.Document)
-		case consts.DoDeleteIndex:
-			err = deleteIndex(req.Index, req.ID)
-		default:
-			err = fmt.Errorf("invalid function type: %d", req.Function)
+		result := applyIndexRequest(req)
+		if result.Err != nil {
+			log.Errorf("Failed to apply index task %d on book %s in %s: %v", req.Function, req.ID, req.Index, result.Err)
 		}
 
-		req.ResponseChan <- &IndexResult{Response: res, Err: err}
+		req.ResponseChan <- result
 		close(req.ResponseChan)
 	}
 
 }
 
-func addToIndex(ctx context.Context, index, id string, doc interface{}) (*esapi.Response, error) {
+func applyIndexRequest(req IndexRequest) *IndexResult {
+	switch {
+	case req.Function == consts.DoPurgeIndex:
+		return applyPurge(req)
+	case req.Function == consts.DoMergeIndex:
+		return applyMerge(req)
+	case redisClient == nil:
+		return applyWrite(req)
+	default:
+		return applyRecordedWrite(req)
+	}
+}
+
+// applyMerge writes the survivor of a merge, and only once that succeeded trashes the duplicates,
+// each as its own recorded write.
+func applyMerge(req IndexRequest) *IndexResult {
+	merge, ok := req.Document.(common.BookMerge)
+	if !ok {
+		return &IndexResult{Err: fmt.Errorf("invalid merge document %T", req.Document)}
+	}
+
+	result := &IndexResult{}
+	if len(merge.Survivor) > 0 {
+		result = applyIndexRequest(req.on(req.ID, merge.Survivor, consts.DoUpdateIndex))
+		if result.Err != nil {
+			return &IndexResult{Err: fmt.Errorf("updating survivor, duplicates left as they are: %w", result.Err)}
+		}
+	}
+	for _, id := range merge.DuplicateIDs {
+		if deleted := applyIndexRequest(req.on(id, merge.Deletion, consts.DoDeleteIndex)); deleted.Err != nil {
+			return &IndexResult{Err: fmt.Errorf("trashing duplicate %s: %w", id, deleted.Err)}
+		}
+	}
+	return result
+}
+
+// on is the same request applied to another book, document and function.
+func (r IndexRequest) on(id string, document interface{}, function consts.Function) IndexRequest {
+	r.ID, r.Document, r.Function = id, document, function
+	return r
+}
+
+// applyPurge deletes a book for good. Purged books leave neither history nor events behind,
+// and a purge that fails puts the book back in the trash. The delete is conditioned on the book
+// still being in the trash, so a restore applied after the purge was queued keeps the book.
+func applyPurge(req IndexRequest) *IndexResult {
+	ctx := context.Background()
+	for {
+		stored, err := getStoredDocument(ctx, req.Index, req.ID)
+		if err != nil {
+			if redisClient != nil {
+				returnToTrash(req)
+			}
+			return &IndexResult{Err: fmt.Errorf("reading book before purging it: %w", err)}
+		}
+		if stored != nil && stored.Source[consts.DeletedAtField] == nil {
+			log.Infof("Book %s left the trash before its purge, keeping it", req.ID)
+			return &IndexResult{Version: stored.Version}
+		}
+
+		var version int64
+		if stored != nil {
+			version, err = deleteIndex(req.Index, req.ID,
+				EsClient.Delete.WithIfSeqNo(stored.SeqNo), EsClient.Delete.WithIfPrimaryTerm(stored.PrimaryTerm))
+		}
+		if errors.Is(err, errVersionConflict) {
+			log.Infof("Book %s changed while purging it, checking it again", req.ID)
+			continue
+		}
+		if errors.Is(err, ErrDocumentNotFound) {
+			// Already gone, the purge is done
+			err = nil
+		}
+		if err != nil {
+			if redisClient != nil {
+				returnToTrash(req)
+			}
+			return &IndexResult{Err: err}
+		}
+
+		InvalidateBookCache(req.Tenant, req.ID)
+		if redisClient != nil {
+			if err := updateTrash(ctx, req); err != nil {
+				log.Errorf("Failed to update the trash for book %s: %v", req.ID, err)
+			}
+		}
+		return &IndexResult{Version: version}
+	}
+}
+
+// applyWrite applies a write with nothing to record it in.
+func applyWrite(req IndexRequest) *IndexResult {
+	res, written, err := writeDocument(req, nil, false)
+	if err != nil {
+		return &IndexResult{Response: res, Err: err}
+	}
+	InvalidateBookCache(req.Tenant, req.ID)
+	return &IndexResult{Response: res, Version: written.Version}
+}
+
+// applyRecordedWrite applies a write and records it in the trash, the revisions and the outbox.
+// The write is conditioned on the book read before it, so the event's before image is exactly
+// what the write replaced; a concurrent write to the same book makes it read the book again, as
+// often as it takes. When the book cannot be read the write still goes ahead, without a before image.
+func applyRecordedWrite(req IndexRequest) *IndexResult {
+	ctx := context.Background()
+	for {
+		var stored *storedDocument
+		conditional := req.Function != consts.DoCreateIndex
+		if conditional {
+			var err error
+			if stored, err = getStoredDocument(ctx, req.Index, req.ID); err != nil {
+				log.Warnf("Could not read book %s before writing it, writing it unconditionally: %v", req.ID, err)
+				conditional = false
+			}
+		}
+
+		event := NewBookEvent(req, stored.source(), stored.version()+1)
+		pending := stagePendingEvent(ctx, req, event)
+		res, written, err := writeDocument(req, stored, conditional)
+		if errors.Is(err, errVersionConflict) {
+			unstagePendingEvent(ctx, pending)
+			log.Infof("Book %s changed while writing it, retrying", req.ID)
+			continue
+		}
+		if err != nil {
+			unstagePendingEvent(ctx, pending)
+			return &IndexResult{Response: res, Err: err}
+		}
+
+		InvalidateBookCache(req.Tenant, req.ID)
+		event.Version = written.Version
+		if written.Source != nil {
+			event.After = appliedDocument(event.Operation, written.Source)
+		}
+		recordWrite(req, event, pending)
+		return &IndexResult{Response: res, Version: written.Version}
+	}
+}
+
+// recordWrite keeps the trash, the revisions and the outbox in step with a write the worker applied.
+func recordWrite(req IndexRequest, event BookEvent, pending string) {
+	ctx := context.Background()
+	if err := updateTrash(ctx, req); err != nil {
+		log.Errorf("Failed to update the trash for book %s: %v", req.ID, err)
+	}
+	if _, err := RecordRevision(ctx, event); err != nil {
+		log.Errorf("Failed to record revision of book %s: %v", req.ID, err)
+	}
+	if err := AppendOutboxEvent(ctx, event, pending); err != nil {
+		log.Errorf("Failed to append %s event of book %s to the outbox: %v", event.Type, req.ID, err)
+		if pending == "" {
+			spillOutboxEvent(event)
+		}
+	}
+}
+
+// writeDocument applies the write of a request. Conditional writes only succeed while the book is
+// still the stored one, or still missing when there is none, and fail with errVersionConflict otherwise.
+func writeDocument(req IndexRequest, stored *storedDocument, conditional bool) (*esapi.Response, writtenDocument, error) {
+	switch req.Function {
+	case consts.DoCreateIndex, consts.DoRestoreIndex:
+		var opts []func(*esapi.IndexRequest)
+		if conditional && stored != nil {
+			opts = append(opts, EsClient.Index.WithIfSeqNo(stored.SeqNo), EsClient.Index.WithIfPrimaryTerm(stored.PrimaryTerm))
+		} else if conditional {
+			opts = append(opts, EsClient.Index.WithOpType("create"))
+		}
+		res, version, err := addToIndex(req.Ctx, req.Index, req.ID, req.Document, opts...)
+		return res, writtenDocument{Version: version, Source: documentMap(req.Document)}, err
+	case consts.DoUpdateIndex, consts.DoDeleteIndex, consts.DoUndeleteIndex:
+		var opts []func(*esapi.UpdateRequest)
+		if conditional && stored != nil {
+			opts = append(opts, EsClient.Update.WithIfSeqNo(stored.SeqNo), EsClient.Update.WithIfPrimaryTerm(stored.PrimaryTerm))
+		}
+		// The book the update left behind is the event's after image
+		opts = append(opts, EsClient.Update.WithSource("true"))
+		written, err := updateIndex(req.Index, req.ID, req.Document, opts...)
+		return nil, written, err
+	}
+	return nil, writtenDocument{}, fmt.Errorf("invalid function type: %d", req.Function)
+}
+
+// storedDocument is a document with the sequence number a conditional write on it must match.
+type storedDocument struct {
+	Source      map[string]interface{} `json:"_source"`
+	Version     int64                  `json:"_version"`
+	SeqNo       int                    `json:"_seq_no"`
+	PrimaryTerm int                    `json:"_primary_term"`
+}
+
+func (d *storedDocument) source() map[string]interface{} {
+	if d == nil {
+		return nil
+	}
+	return d.Source
+}
+
+func (d *storedDocument) version() int64 {
+	if d == nil {
+		return 0
+	}
+	return d.Version
+}
+
+// writtenDocument is the outcome of a write: the new version and, when known, the document it left.
+type writtenDocument struct {
+	Version int64
+	Source  map[string]interface{}
+}
+
+// GetBook reads a book of the tenant behind ctx straight from Elasticsearch, bypassing the cache.
+// It returns nil when the book does not exist or is in the trash.
+func GetBook(ctx context.Context, id string) (map[string]interface{}, error) {
+	book, err := getDocument(ctx, booksIndexFor(ctx), id)
+	if err != nil || book == nil || book[consts.DeletedAtField] != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// getDocument returns the source of a document, or nil when it does not exist.
+func getDocument(ctx context.Context, index, id string) (map[string]interface{}, error) {
+	stored, err := getStoredDocument(ctx, index, id)
+	return stored.source(), err
+}
+
+// getStoredDocument returns a document with its version and sequence number, or nil when it does not exist.
+func getStoredDocument(ctx context.Context, index, id string) (*storedDocument, error) {
 	if EsClient == nil {
 		return nil, errors.New("elasticsearch client not initialized")
 	}
 
+	res, err := EsClient.Get(index, id, EsClient.Get.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("get returned error: %s", res.String())
+	}
+
+	var stored storedDocument
+	if err := json.NewDecoder(res.Body).Decode(&stored); err != nil {
+		return nil, fmt.Errorf("error parsing get response: %w", err)
+	}
+	return &stored, nil
+}
+
+// documentVersion reads the _version of a write response, leaving the body readable.
+func documentVersion(res *esapi.Response) int64 {
+	return decodeWrite(res).Version
+}
+
+// decodeWrite reads the version of a write response and the source an update returned,
+// leaving the body readable.
+func decodeWrite(res *esapi.Response) writtenDocument {
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return writtenDocument{}
+	}
+
+	var body struct {
+		Version int64 `json:"_version"`
+		Get     struct {
+			Source map[string]interface{} `json:"_source"`
+		} `json:"get"`
+	}
+	_ = json.Unmarshal(data, &body)
+	return writtenDocument{Version: body.Version, Source: body.Get.Source}
+}
+
+func addToIndex(ctx context.Context, index, id string, doc interface{}, opts ...func(*esapi.IndexRequest)) (*esapi.Response, int64, error) {
+	if EsClient == nil {
+		return nil, 0, errors.New("elasticsearch client not initialized")
+	}
+
 	if doc == nil {
-		return nil, fmt.Errorf("document cannot be nil")
+		return nil, 0, fmt.Errorf("document cannot be nil")
 	}
 
-	res, err := EsClient.Index(
-		index,
-		esutil.NewJSONReader(doc),
-		EsClient.Index.WithContext(ctx),
-		EsClient.Index.WithDocumentID(id),
-	)
+	opts = append([]func(*esapi.IndexRequest){EsClient.Index.WithContext(ctx), EsClient.Index.WithDocumentID(id)}, opts...)
+	res, err := EsClient.Index(index, esutil.NewJSONReader(doc), opts...)
 	if err != nil {
 		log.Errorf("Index request failed: %v", err)
-		return nil, fmt.Errorf("index request failed: %w", err)
+		return nil, 0, fmt.Errorf("index request failed: %w", err)
 	}
 
+	if res.StatusCode == http.StatusConflict {
+		return res, 0, fmt.Errorf("%w: %s", errVersionConflict, id)
+	}
 	if res.IsError() {
 		log.Errorf("Index returned error: %s", res.String())
-		return res, fmt.Errorf("index returned error: %s", res.String())
+		return res, 0, fmt.Errorf("index returned error: %s", res.String())
 	}
 
 	log.Infof("Document %s indexed successfully in %s", id, index)
-	return res, nil
+	return res, documentVersion(res), nil
 }
 
-func deleteIndex(index, docID string) error {
-	res, err := EsClient.Delete(index, docID)
+func deleteIndex(index, docID string, opts ...func(*esapi.DeleteRequest)) (int64, error) {
+	res, err := EsClient.Delete(index, docID, opts...)
 	if err != nil {
-		return fmt.Errorf("error deleting document: %w", err)
+		return 0, fmt.Errorf("error deleting document: %w", err)
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotFound {
+		return 0, fmt.Errorf("%w: %s", ErrDocumentNotFound, docID)
+	}
+	if res.StatusCode == http.StatusConflict {
+		return 0, fmt.Errorf("%w: %s", errVersionConflict, docID)
+	}
 	if res.IsError() {
-		return fmt.Errorf("error deleting document: %s", res.String())
+		return 0, fmt.Errorf("error deleting document: %s", res.String())
 	}
 
 	log.Infof("Document %s deleted successfully from index %s", docID, index)
-	return nil
+	return documentVersion(res), nil
 }
 
-func updateIndex(index, docID string, updateData interface{}) error {
+func updateIndex(index, docID string, updateData interface{}, opts ...func(*esapi.UpdateRequest)) (writtenDocument, error) {
 	updateBody, err := json.Marshal(map[string]interface{}{
 		"doc": updateData,
 	})
 	if err != nil {
-		return fmt.Errorf("error marshalling update data: %w", err)
+		return writtenDocument{}, fmt.Errorf("error marshalling update data: %w", err)
 	}
 
-	res, err := EsClient.Update(index, docID, bytes.NewReader(updateBody))
+	res, err := EsClient.Update(index, docID, bytes.NewReader(updateBody), opts...)
 	if err != nil {
-		return fmt.Errorf("error updating document: %w", err)
+		return writtenDocument{}, fmt.Errorf("error updating document: %w", err)
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusConflict {
+		return writtenDocument{}, fmt.Errorf("%w: %s", errVersionConflict, docID)
+	}
 	if res.IsError() {
-		return fmt.Errorf("error updating document: %s", res.String())
+		return writtenDocument{}, fmt.Errorf("error updating document: %s", res.String())
 	}
 
 	log.Infof("Document %s updated successfully in index %s", docID, index)
-	return nil
+	return decodeWrite(res), nil
 }
 
 // mergeSearchOptions merges two slices of functions with the following rules:
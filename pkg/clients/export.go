@@ -0,0 +1,96 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+var actionCSVHeader = []string{
+	"id", "time", "user", "tenant", "method", "path", "route", "status", "error_category",
+	"latency_ms", "request_id", "book_id", "changes", "sample_rate",
+}
+
+// ActionWriter encodes exported actions as NDJSON or CSV.
+type ActionWriter interface {
+	Write(action UserAction) error
+	// Flush pushes buffered output to the underlying writer
+	Flush() error
+}
+
+func NewActionWriter(w io.Writer, format string) ActionWriter {
+	if format == consts.ExportCSV {
+		return &csvActionWriter{writer: csv.NewWriter(w)}
+	}
+	return &ndjsonActionWriter{encoder: json.NewEncoder(w)}
+}
+
+type ndjsonActionWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonActionWriter) Write(action UserAction) error {
+	return w.encoder.Encode(action)
+}
+
+func (w *ndjsonActionWriter) Flush() error {
+	return nil
+}
+
+type csvActionWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvActionWriter) Write(action UserAction) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(actionCSVHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	changes := ""
+	if len(action.Changes) > 0 {
+		data, err := json.Marshal(action.Changes)
+		if err != nil {
+			return err
+		}
+		changes = string(data)
+	}
+	sampleRate := ""
+	if action.SampleRate > 0 {
+		sampleRate = strconv.FormatFloat(action.SampleRate, 'f', -1, 64)
+	}
+
+	return w.writer.Write([]string{
+		action.ID,
+		action.Time.UTC().Format(time.RFC3339Nano),
+		action.User,
+		action.Tenant,
+		action.Method,
+		action.Path,
+		action.Route,
+		strconv.Itoa(action.Status),
+		action.ErrorCategory,
+		strconv.FormatInt(action.LatencyMs, 10),
+		action.RequestID,
+		action.BookID,
+		changes,
+		sampleRate,
+	})
+}
+
+func (w *csvActionWriter) Flush() error {
+	if !w.headerWritten {
+		if err := w.writer.Write(actionCSVHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,99 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrIdempotencyMismatch   = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+// IdempotentRequest is what is kept under an idempotency key: the fingerprint of the request
+// and, once it completed, its response.
+type IdempotentRequest struct {
+	State       string    `json:"state"`
+	Fingerprint string    `json:"fingerprint"`
+	Status      int       `json:"status,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// reserveIdempotencyKeyScript claims a key, or returns what it already holds.
+var reserveIdempotencyKeyScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return false
+end
+return redis.call('GET', KEYS[1])
+`)
+
+// BeginIdempotentRequest claims key for a request. It returns nil when the request should run,
+// the stored request when it already completed, ErrIdempotencyInProgress while it still runs
+// and ErrIdempotencyMismatch when the key was used for another request.
+func BeginIdempotentRequest(ctx context.Context, key, fingerprint string) (*IdempotentRequest, error) {
+	if redisClient == nil {
+		return nil, errors.New("redis client not initialized")
+	}
+
+	data, err := json.Marshal(IdempotentRequest{
+		State:       consts.IdempotencyStateProcessing,
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := reserveIdempotencyKeyScript.Run(ctx, redisClient, []string{key},
+		data, consts.IdempotencyLockTTL.Milliseconds(),
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reserving idempotency key: %w", err)
+	}
+
+	var existing IdempotentRequest
+	if err := json.Unmarshal([]byte(stored), &existing); err != nil {
+		return nil, fmt.Errorf("decoding idempotency key: %w", err)
+	}
+	switch {
+	case existing.Fingerprint != fingerprint:
+		return nil, ErrIdempotencyMismatch
+	case existing.State != consts.IdempotencyStateCompleted:
+		return nil, ErrIdempotencyInProgress
+	}
+	return &existing, nil
+}
+
+// CompleteIdempotentRequest stores the response of a request for ttl, for repeats to replay.
+func CompleteIdempotentRequest(ctx context.Context, key string, request IdempotentRequest, ttl time.Duration) error {
+	request.State = consts.IdempotencyStateCompleted
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	return redisClient.Set(ctx, key, data, ttl).Err()
+}
+
+// ReleaseIdempotentRequest frees a key whose request failed, so it can be retried.
+func ReleaseIdempotentRequest(ctx context.Context, key string) error {
+	return redisClient.Del(ctx, key).Err()
+}
+
+// IdempotencyKey scopes a client supplied key to its tenant and user.
+func IdempotencyKey(tenant, user, key string) string {
+	hash := sha256.Sum256([]byte(tenant + "\n" + user + "\n" + key))
+	return redisKey("idempotency:" + hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,119 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	knownTenants   sync.Map
+	unknownTenants = utils.NewTTLCache[struct{}](consts.UnknownTenantCacheTTL, consts.TenantCacheSize)
+)
+
+// IndexName resolves the physical name of a logical index as <INDEX_PREFIX>-<base>-<INDEX_SUFFIX>.
+// The base defaults to the logical name and can be overridden with <LOGICAL>_INDEX, e.g. BOOKS_INDEX.
+func IndexName(logical string) string {
+	return TenantIndexName(logical, "")
+}
+
+// TenantIndexName resolves the physical index of a tenant, <INDEX_PREFIX>-<base>-<tenant>-<INDEX_SUFFIX>.
+// The empty tenant maps to the shared index.
+func TenantIndexName(logical, tenant string) string {
+	base, _ := utils.GetEnvVar[string](strings.ToUpper(logical)+"_INDEX", logical)
+	prefix, _ := utils.GetEnvVar[string]("INDEX_PREFIX", "")
+	suffix, _ := utils.GetEnvVar[string]("INDEX_SUFFIX", "")
+	return utils.FormatIndexName(prefix, base, tenant, suffix)
+}
+
+// TenantFromContext returns the tenant set by the tenant middleware, or "" for the shared catalogue.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(consts.TenantKey).(string)
+	return tenant
+}
+
+// actorFromContext is the caller Authenticate resolved for the request behind ctx.
+func actorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(consts.ActorKey).(string)
+	return actor
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(consts.RequestIDKey).(string)
+	return requestID
+}
+
+func booksIndexFor(ctx context.Context) string {
+	return TenantIndexName(consts.BooksIndex, TenantFromContext(ctx))
+}
+
+// ProvisionTenant creates the managed indices of a tenant, leaving those that already exist as they are.
+// Requests can only select a tenant once it is provisioned.
+func ProvisionTenant(tenant string) ([]string, error) {
+	if EsClient == nil {
+		return nil, errors.New("elasticsearch client not initialized")
+	}
+
+	indices := make([]string, 0, len(consts.IndexMappings))
+	for _, indexMapping := range consts.IndexMappings {
+		index := TenantIndexName(indexMapping.IndexName, tenant)
+		if err := createIndex(EsClient, index, indexMapping.Mapping); err != nil {
+			return nil, err
+		}
+		indices = append(indices, index)
+	}
+	knownTenants.Store(tenant, struct{}{})
+	unknownTenants.Delete(tenant)
+	return indices, nil
+}
+
+// TenantExists reports whether a tenant was provisioned, that is whether its books index exists.
+// Known tenants are remembered, unknown ones for consts.UnknownTenantCacheTTL.
+func TenantExists(ctx context.Context, tenant string) (bool, error) {
+	if tenant == "" {
+		return true, nil
+	}
+	if _, ok := knownTenants.Load(tenant); ok {
+		return true, nil
+	}
+	if _, ok := unknownTenants.Get(tenant, time.Now()); ok {
+		return false, nil
+	}
+	if EsClient == nil {
+		return false, errors.New("elasticsearch client not initialized")
+	}
+
+	res, err := EsClient.Indices.Exists(
+		[]string{TenantIndexName(consts.BooksIndex, tenant)},
+		EsClient.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return false, fmt.Errorf("checking tenant %s: %w", tenant, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		knownTenants.Store(tenant, struct{}{})
+		return true, nil
+	case http.StatusNotFound:
+		unknownTenants.Set(tenant, struct{}{}, time.Now())
+		return false, nil
+	}
+	return false, fmt.Errorf("checking tenant %s: %s", tenant, res.String())
+}
@@ -0,0 +1,134 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+	log "github.com/sirupsen/logrus"
+)
+
+var ErrIncompatibleMapping = errors.New("incompatible index mapping")
+
+type IndexStatus struct {
+	Index  string               `json:"index"`
+	Ready  bool                 `json:"ready"`
+	Drifts []utils.MappingDrift `json:"drifts,omitempty"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// CheckIndexMappings compares the live mapping of every managed index with its expected definition.
+func CheckIndexMappings(ctx context.Context) []IndexStatus {
+	statuses := make([]IndexStatus, 0, len(consts.IndexMappings))
+	for _, indexMapping := range consts.IndexMappings {
+		index := IndexName(indexMapping.IndexName)
+		status := IndexStatus{Index: index}
+
+		drifts, err := checkIndexMapping(ctx, index, indexMapping.Mapping)
+		if err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Drifts = drifts
+			status.Ready = !utils.HasIncompatibleDrift(drifts)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func checkIndexMapping(ctx context.Context, index, mapping string) ([]utils.MappingDrift, error) {
+	if EsClient == nil {
+		return nil, errors.New("elasticsearch client not initialized")
+	}
+
+	expected, err := utils.ParseMapping(mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := getLiveMapping(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.CompareMappings(expected, actual), nil
+}
+
+func getLiveMapping(ctx context.Context, index string) (map[string]interface{}, error) {
+	res, err := EsClient.Indices.GetMapping(
+		EsClient.Indices.GetMapping.WithContext(ctx),
+		EsClient.Indices.GetMapping.WithIndex(index),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get mapping request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("get mapping returned error: %s", res.String())
+	}
+
+	var body map[string]struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error parsing mapping response: %w", err)
+	}
+
+	// The response is keyed by the concrete index name, which differs from the requested one behind an alias
+	for _, indexBody := range body {
+		return indexBody.Mappings.Properties, nil
+	}
+	return nil, fmt.Errorf("no mapping returned for index %s", index)
+}
+
+func logDrifts(index string, drifts []utils.MappingDrift) {
+	for _, drift := range drifts {
+		log.WithFields(log.Fields{
+			"index":        index,
+			"field":        drift.Field,
+			"kind":         drift.Kind,
+			"expected":     drift.Expected,
+			"actual":       drift.Actual,
+			"incompatible": drift.Incompatible,
+		}).Warn("Index mapping drift detected")
+	}
+}
+
+// addMissingFields puts the expected mapping on an existing index so newly declared fields get mapped.
+// Only safe when the drift contains nothing but missing fields.
+func addMissingFields(ctx context.Context, index, mapping string) error {
+	var definition struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(mapping), &definition); err != nil {
+		return fmt.Errorf("invalid mapping json: %w", err)
+	}
+
+	res, err := EsClient.Indices.PutMapping(
+		esutil.NewJSONReader(definition.Mappings),
+		EsClient.Indices.PutMapping.WithContext(ctx),
+		EsClient.Indices.PutMapping.WithIndex(index),
+	)
+	if err := decodeResponse(res, err, nil); err != nil {
+		return fmt.Errorf("put mapping failed: %w", err)
+	}
+
+	log.Infof("Added missing fields to the mapping of index %s", index)
+	return nil
+}
+
+func hasMissingFields(drifts []utils.MappingDrift) bool {
+	for _, drift := range drifts {
+		if drift.Kind == consts.DriftMissingField {
+			return true
+		}
+	}
+	return false
+}
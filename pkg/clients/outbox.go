@@ -0,0 +1,513 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	outboxField = "event"
+)
+
+type OutboxSinkStatus struct {
+	Name        string     `json:"name"`
+	Offset      string     `json:"offset,omitempty"`
+	Running     bool       `json:"running"`
+	Published   int64      `json:"published"`
+	Failed      int64      `json:"failed"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type OutboxStatus struct {
+	Length       int64              `json:"length"`
+	LastID       string             `json:"last_id,omitempty"`
+	Appended     int64              `json:"appended"`
+	AppendFailed int64              `json:"append_failed"`
+	Pending      int64              `json:"pending"`
+	Spilled      int64              `json:"spilled"`
+	Replayed     int64              `json:"replayed"`
+	Dropped      int64              `json:"dropped"`
+	Sinks        []OutboxSinkStatus `json:"sinks"`
+}
+
+// outboxRelay moves outbox entries to one sink. Only the replica holding the sink's lock relays it.
+type outboxRelay struct {
+	sink      OutboxSink
+	published atomic.Int64
+	failed    atomic.Int64
+
+	mutex       sync.Mutex
+	lastError   string
+	lastErrorAt *time.Time
+}
+
+// acquireOutboxLockScript takes or extends the lock of a sink for this replica.
+var acquireOutboxLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// commitOutboxOffsetScript stores an offset only while this replica still holds the lock,
+// so a replica that lost it mid batch cannot move the offset back.
+var commitOutboxOffsetScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[2])
+return 1
+`)
+
+var (
+	outboxAppended     atomic.Int64
+	outboxAppendFailed atomic.Int64
+
+	outboxInstance = uuid.NewString()
+	outboxStop     context.CancelFunc
+	outboxWorkers  sync.WaitGroup
+	outboxRelays   []*outboxRelay
+)
+
+// NewBookEvent describes an index operation the worker applied, given the document before it.
+func NewBookEvent(req IndexRequest, before map[string]interface{}, version int64) BookEvent {
+	event := BookEvent{
+		ID:         uuid.NewString(),
+		Type:       consts.FunctionEvents[req.Function],
+		Operation:  consts.FunctionOperations[req.Function],
+		Tenant:     req.Tenant,
+		BookID:     req.ID,
+		Version:    version,
+		Actor:      req.Actor,
+		RequestID:  req.RequestID,
+		OccurredAt: time.Now().UTC(),
+	}
+
+	switch req.Function {
+	case consts.DoCreateIndex:
+		event.After = documentMap(req.Document)
+	case consts.DoUpdateIndex:
+		event.Before = before
+		event.After = mergeDocument(before, documentMap(req.Document))
+	case consts.DoDeleteIndex:
+		event.Before = before
+	case consts.DoUndeleteIndex:
+		event.Before = before
+		event.After = lo.OmitByKeys(mergeDocument(before, nil), []string{consts.DeletedAtField, consts.DeletedByField})
+	case consts.DoRestoreIndex:
+		event.Before = before
+		event.After = documentMap(req.Document)
+		if before == nil {
+			event.Type = consts.EventBookCreated
+		}
+	}
+	return event
+}
+
+// AppendOutboxEvent adds an event to the outbox, together with dropping the pending entry staged for it.
+func AppendOutboxEvent(ctx context.Context, event BookEvent, pending string) error {
+	if redisClient == nil {
+		return errors.New("redis client not initialized")
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	pipe := redisClient.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: outboxStream(),
+		MaxLen: consts.OutboxMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{outboxField: data},
+	})
+	if pending != "" {
+		pipe.HDel(ctx, outboxPendingKey(), pending)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		outboxAppendFailed.Add(1)
+		return fmt.Errorf("appending to outbox: %w", err)
+	}
+	outboxAppended.Add(1)
+	return nil
+}
+
+// StartOutboxRelay relays the outbox to the sinks named in OUTBOX_SINKS, one goroutine per sink.
+func StartOutboxRelay() {
+	names, _ := utils.GetEnvVar[string]("OUTBOX_SINKS", consts.SinkWebhooks)
+	ctx, cancel := context.WithCancel(context.Background())
+	outboxStop = cancel
+	outboxSpill = loadOutboxSpill()
+
+	outboxWorkers.Add(1)
+	go func() {
+		defer outboxWorkers.Done()
+		recoverOutbox(ctx)
+	}()
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		sink, err := newOutboxSink(name)
+		if err != nil {
+			log.Errorf("Outbox sink %s disabled: %v", name, err)
+			continue
+		}
+
+		relay := &outboxRelay{sink: sink}
+		outboxRelays = append(outboxRelays, relay)
+		outboxWorkers.Add(1)
+		go func() {
+			defer outboxWorkers.Done()
+			relay.run(ctx)
+		}()
+		log.Infof("Relaying the outbox to %s", name)
+	}
+}
+
+// StopOutboxRelay stops relaying once the batches in flight are published.
+func StopOutboxRelay() {
+	if outboxStop == nil {
+		return
+	}
+	outboxStop()
+	outboxWorkers.Wait()
+
+	for _, relay := range outboxRelays {
+		if closer, ok := relay.sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Warnf("Failed to close outbox sink %s: %v", relay.sink.Name(), err)
+			}
+		}
+	}
+}
+
+// GetOutboxStatus reports the outbox and the offset of every sink that consumed it.
+func GetOutboxStatus(ctx context.Context) (OutboxStatus, error) {
+	if redisClient == nil {
+		return OutboxStatus{}, errors.New("redis client not initialized")
+	}
+
+	status := OutboxStatus{
+		Appended:     outboxAppended.Load(),
+		AppendFailed: outboxAppendFailed.Load(),
+		Spilled:      outboxSpilled.Load(),
+		Replayed:     outboxReplayed.Load(),
+		Dropped:      outboxDropped.Load(),
+	}
+	length, err := redisClient.XLen(ctx, outboxStream()).Result()
+	if err != nil {
+		return OutboxStatus{}, fmt.Errorf("reading outbox length: %w", err)
+	}
+	status.Length = length
+
+	pending, err := redisClient.HLen(ctx, outboxPendingKey()).Result()
+	if err != nil {
+		return OutboxStatus{}, fmt.Errorf("reading pending outbox events: %w", err)
+	}
+	status.Pending = pending
+
+	lastID, err := lastOutboxID(ctx)
+	if err != nil {
+		return OutboxStatus{}, err
+	}
+	status.LastID = lastID
+
+	names, err := redisClient.SMembers(ctx, outboxSinksKey()).Result()
+	if err != nil {
+		return OutboxStatus{}, fmt.Errorf("listing outbox sinks: %w", err)
+	}
+	sinks := make(map[string]*OutboxSinkStatus, len(names))
+	for _, name := range names {
+		offset, err := redisClient.Get(ctx, outboxOffsetKey(name)).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return OutboxStatus{}, fmt.Errorf("reading offset of outbox sink %s: %w", name, err)
+		}
+		sinks[name] = &OutboxSinkStatus{Name: name, Offset: offset}
+	}
+	for _, relay := range outboxRelays {
+		sink, ok := sinks[relay.sink.Name()]
+		if !ok {
+			sink = &OutboxSinkStatus{Name: relay.sink.Name()}
+			sinks[sink.Name] = sink
+		}
+		sink.Running = true
+		sink.Published = relay.published.Load()
+		sink.Failed = relay.failed.Load()
+		relay.mutex.Lock()
+		sink.LastError, sink.LastErrorAt = relay.lastError, relay.lastErrorAt
+		relay.mutex.Unlock()
+	}
+
+	status.Sinks = make([]OutboxSinkStatus, 0, len(sinks))
+	for _, sink := range sinks {
+		status.Sinks = append(status.Sinks, *sink)
+	}
+	sort.Slice(status.Sinks, func(i, j int) bool { return status.Sinks[i].Name < status.Sinks[j].Name })
+	return status, nil
+}
+
+// RemoveOutboxSink forgets the offset of a sink that is no longer used, so it stops holding back trimming.
+// A replica still relaying to it starts over from the end of the outbox.
+func RemoveOutboxSink(ctx context.Context, name string) error {
+	if redisClient == nil {
+		return errors.New("redis client not initialized")
+	}
+
+	pipe := redisClient.TxPipeline()
+	pipe.SRem(ctx, outboxSinksKey(), name)
+	pipe.Del(ctx, outboxOffsetKey(name))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("removing outbox sink %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *outboxRelay) run(ctx context.Context) {
+	name := r.sink.Name()
+	failures := 0
+	for ctx.Err() == nil {
+		held, err := acquireOutboxLockScript.Run(ctx, redisClient, []string{outboxLockKey(name)},
+			outboxInstance, consts.OutboxLockLease.Milliseconds(),
+		).Int()
+		if err != nil || held == 0 {
+			if err != nil && ctx.Err() == nil {
+				log.Warnf("Failed to lock outbox sink %s: %v", name, err)
+			}
+			sleepContext(ctx, consts.OutboxPollInterval)
+			continue
+		}
+
+		if err := r.relayBatch(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			r.recordFailure(err)
+			log.Warnf("Failed to relay the outbox to %s: %v", name, err)
+			sleepContext(ctx, utils.RetryBackoff(failures, consts.OutboxRetryBase, consts.OutboxRetryMax))
+			continue
+		}
+		failures = 0
+	}
+}
+
+// relayBatch publishes the entries after the sink's offset and commits the offset past them.
+// A crash between the two republishes the batch, which is what makes delivery at least once.
+func (r *outboxRelay) relayBatch(ctx context.Context) error {
+	name := r.sink.Name()
+	offset, err := outboxOffset(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	streams, err := redisClient.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{outboxStream(), offset},
+		Count:   consts.OutboxBatchSize,
+		Block:   consts.OutboxPollInterval,
+	}).Result()
+	if errors.Is(err, redis.Nil) || (err == nil && len(streams) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading outbox: %w", err)
+	}
+
+	messages := streams[0].Messages
+	if len(messages) == 0 {
+		return nil
+	}
+	events := make([]BookEvent, 0, len(messages))
+	for _, message := range messages {
+		event, err := decodeOutboxEntry(message)
+		if err != nil {
+			log.Errorf("Skipping outbox entry %s: %v", message.ID, err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if len(events) > 0 {
+		if err := r.sink.Publish(ctx, events); err != nil {
+			r.failed.Add(int64(len(events)))
+			return err
+		}
+		r.published.Add(int64(len(events)))
+	}
+
+	last := messages[len(messages)-1].ID
+	committed, err := commitOutboxOffsetScript.Run(ctx, redisClient,
+		[]string{outboxLockKey(name), outboxOffsetKey(name)}, outboxInstance, last,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("committing offset of outbox sink %s: %w", name, err)
+	}
+	if committed == 0 {
+		log.Warnf("Lost the lock of outbox sink %s, another replica takes over", name)
+		return nil
+	}
+
+	trimOutbox(ctx)
+	return nil
+}
+
+func (r *outboxRelay) recordFailure(err error) {
+	now := time.Now().UTC()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lastError = err.Error()
+	r.lastErrorAt = &now
+}
+
+// outboxOffset returns the last entry a sink published. A sink seen for the first time starts at
+// the end of the outbox rather than replaying it.
+func outboxOffset(ctx context.Context, name string) (string, error) {
+	offset, err := redisClient.Get(ctx, outboxOffsetKey(name)).Result()
+	if err == nil {
+		return offset, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("reading offset of outbox sink %s: %w", name, err)
+	}
+
+	lastID, err := lastOutboxID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if lastID == "" {
+		lastID = "0-0"
+	}
+	pipe := redisClient.TxPipeline()
+	pipe.SetNX(ctx, outboxOffsetKey(name), lastID, 0)
+	pipe.SAdd(ctx, outboxSinksKey(), name)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("initializing offset of outbox sink %s: %w", name, err)
+	}
+	return redisClient.Get(ctx, outboxOffsetKey(name)).Result()
+}
+
+// trimOutbox drops the entries every known sink has published, keeping CHANGES_RETENTION
+// worth of them for change stream clients to resume from.
+func trimOutbox(ctx context.Context) {
+	names, err := redisClient.SMembers(ctx, outboxSinksKey()).Result()
+	if err != nil || len(names) == 0 {
+		return
+	}
+
+	minID := changesRetentionID()
+	for _, name := range names {
+		offset, err := redisClient.Get(ctx, outboxOffsetKey(name)).Result()
+		if err != nil {
+			return
+		}
+		if utils.CompareStreamIDs(offset, minID) < 0 {
+			minID = offset
+		}
+	}
+	if err := redisClient.XTrimMinIDApprox(ctx, outboxStream(), minID, 0).Err(); err != nil {
+		log.Warnf("Failed to trim the outbox: %v", err)
+	}
+}
+
+func lastOutboxID(ctx context.Context) (string, error) {
+	last, err := redisClient.XRevRangeN(ctx, outboxStream(), "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("reading outbox: %w", err)
+	}
+	if len(last) == 0 {
+		return "", nil
+	}
+	return last[0].ID, nil
+}
+
+func decodeOutboxEntry(message redis.XMessage) (BookEvent, error) {
+	var event BookEvent
+	data, ok := message.Values[outboxField].(string)
+	if !ok {
+		return event, fmt.Errorf("missing %s field", outboxField)
+	}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return event, err
+	}
+	return event, nil
+}
+
+// documentMap turns a written document into its field map.
+func documentMap(document interface{}) map[string]interface{} {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// mergeDocument applies a partial update the way Elasticsearch does, merging nested objects.
+func mergeDocument(document, update map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(document)+len(update))
+	for field, value := range document {
+		merged[field] = value
+	}
+	for field, value := range update {
+		nested, isObject := value.(map[string]interface{})
+		current, wasObject := merged[field].(map[string]interface{})
+		if isObject && wasObject {
+			merged[field] = mergeDocument(current, nested)
+			continue
+		}
+		merged[field] = value
+	}
+	return merged
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func outboxOffsetKey(sink string) string {
+	return redisKey("outbox:offset:" + sink)
+}
+
+func outboxLockKey(sink string) string {
+	return redisKey("outbox:lock:" + sink)
+}
+
+func outboxStream() string {
+	return redisKey("outbox:books")
+}
+
+// outboxSinksKey lists every sink that ever consumed the outbox; the stream is only trimmed
+// past what all of them committed
+func outboxSinksKey() string {
+	return redisKey("outbox:sinks")
+}
@@ -0,0 +1,253 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+// pendingEvent is an event staged before its write, with the fields the write sets so the relay
+// can tell from the stored book whether the write was applied.
+type pendingEvent struct {
+	Event    BookEvent              `json:"event"`
+	Index    string                 `json:"index"`
+	Written  map[string]interface{} `json:"written"`
+	StagedAt time.Time              `json:"staged_at"`
+}
+
+var (
+	outboxSpill    *utils.SpillBuffer
+	outboxSpilled  atomic.Int64
+	outboxReplayed atomic.Int64
+	outboxDropped  atomic.Int64
+)
+
+// stagePendingEvent records the event of a write before it is applied, so it reaches the outbox
+// even when the worker dies before appending it. It returns the pending entry, empty when staging failed.
+func stagePendingEvent(ctx context.Context, req IndexRequest, event BookEvent) string {
+	data, err := json.Marshal(pendingEvent{
+		Event:    event,
+		Index:    req.Index,
+		Written:  documentMap(req.Document),
+		StagedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Errorf("Failed to marshal pending event of book %s: %v", req.ID, err)
+		return ""
+	}
+
+	field := event.Tenant + ":" + event.BookID + ":" + strconv.FormatInt(event.Version, 10) + ":" + event.ID
+	if err := redisClient.HSet(ctx, outboxPendingKey(), field, data).Err(); err != nil {
+		log.Warnf("Failed to stage %s event of book %s: %v", event.Type, req.ID, err)
+		return ""
+	}
+	return field
+}
+
+// unstagePendingEvent forgets the event of a write that was not applied.
+func unstagePendingEvent(ctx context.Context, pending string) {
+	if pending == "" {
+		return
+	}
+	if err := redisClient.HDel(ctx, outboxPendingKey(), pending).Err(); err != nil {
+		log.Warnf("Failed to drop pending outbox event %s: %v", pending, err)
+	}
+}
+
+// appliedDocument is the book an operation left behind, as the event reports it.
+func appliedDocument(operation string, source map[string]interface{}) map[string]interface{} {
+	switch operation {
+	case consts.OperationDelete:
+		return nil
+	case consts.OperationUndelete:
+		// Drops the trash markers the undelete cleared
+		return lo.OmitBy(source, func(_ string, value interface{}) bool { return value == nil })
+	}
+	return source
+}
+
+// spillOutboxEvent keeps an event that could neither be staged nor appended on disk until Redis
+// accepts writes again. Without a usable spill buffer the event is dropped.
+func spillOutboxEvent(event BookEvent) {
+	err := errors.New("no spill buffer configured")
+	if outboxSpill != nil {
+		var data []byte
+		if data, err = json.Marshal(event); err == nil {
+			err = outboxSpill.Append([][]byte{data})
+		}
+	}
+	if err != nil {
+		outboxDropped.Add(1)
+		log.Errorf("Dropping %s event of book %s: %v", event.Type, event.BookID, err)
+		return
+	}
+	outboxSpilled.Add(1)
+}
+
+func loadOutboxSpill() *utils.SpillBuffer {
+	spillDir, _ := utils.GetEnvVar[string]("OUTBOX_SPILL_DIR", "")
+	if spillDir == "" {
+		return nil
+	}
+	maxBytes, _ := utils.GetEnvVar[int]("OUTBOX_SPILL_MAX_BYTES", consts.DefaultOutboxSpillMaxBytes)
+	spill, err := utils.NewSpillBuffer(spillDir, int64(maxBytes))
+	if err != nil {
+		log.Errorf("Outbox spill buffer unavailable: %v", err)
+		return nil
+	}
+	return spill
+}
+
+// recoverOutbox appends the spilled events and the pending ones whose worker never appended them,
+// every OUTBOX_RECOVER_INTERVAL.
+func recoverOutbox(ctx context.Context) {
+	interval, _ := utils.GetEnvVar[time.Duration]("OUTBOX_RECOVER_INTERVAL", consts.DefaultOutboxRecoverInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			replaySpilledEvents(ctx)
+			if err := confirmPendingEvents(ctx, interval); err != nil && ctx.Err() == nil {
+				log.Warnf("Failed to confirm pending outbox events: %v", err)
+			}
+		}
+	}
+}
+
+func replaySpilledEvents(ctx context.Context) {
+	if outboxSpill == nil || outboxSpill.Size() == 0 {
+		return
+	}
+
+	replayed, err := outboxSpill.Drain(consts.OutboxBatchSize, func(records [][]byte) error {
+		pipe := redisClient.TxPipeline()
+		for _, record := range records {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: outboxStream(),
+				MaxLen: consts.OutboxMaxLen,
+				Approx: true,
+				Values: map[string]interface{}{outboxField: record},
+			})
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	outboxReplayed.Add(int64(replayed))
+	if err != nil {
+		log.Warnf("Replaying spilled outbox events stopped after %d: %v", replayed, err)
+	} else if replayed > 0 {
+		log.Infof("Replayed %d spilled outbox events", replayed)
+	}
+}
+
+// confirmPendingEvents checks the events staged longer than consts.OutboxPendingGrace against
+// Elasticsearch, appending those whose write was applied and dropping the others. One replica
+// confirms per interval.
+func confirmPendingEvents(ctx context.Context, interval time.Duration) error {
+	locked, err := redisClient.SetNX(ctx, outboxPendingLockKey(), outboxInstance, interval/2).Result()
+	if err != nil || !locked {
+		return err
+	}
+
+	var cursor uint64
+	for ctx.Err() == nil {
+		entries, next, err := redisClient.HScan(ctx, outboxPendingKey(), cursor, "", consts.OutboxBatchSize).Result()
+		if err != nil {
+			return fmt.Errorf("reading pending outbox events: %w", err)
+		}
+		for i := 0; i+1 < len(entries); i += 2 {
+			confirmPendingEvent(ctx, entries[i], entries[i+1])
+		}
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	return nil
+}
+
+func confirmPendingEvent(ctx context.Context, field, data string) {
+	var pending pendingEvent
+	if err := json.Unmarshal([]byte(data), &pending); err != nil {
+		log.Errorf("Discarding unreadable pending outbox event %s: %v", field, err)
+		unstagePendingEvent(ctx, field)
+		return
+	}
+	if time.Since(pending.StagedAt) < consts.OutboxPendingGrace {
+		// Its worker may still be writing
+		return
+	}
+
+	event := pending.Event
+	stored, err := getStoredDocument(ctx, pending.Index, event.BookID)
+	if err != nil {
+		log.Warnf("Could not read book %s to confirm its pending %s event: %v", event.BookID, event.Type, err)
+		return
+	}
+	if !pending.appliedTo(stored) {
+		log.Warnf("Discarding pending %s event of book %s, its write was not applied", event.Type, event.BookID)
+		unstagePendingEvent(ctx, field)
+		return
+	}
+
+	event.Version = stored.Version
+	event.After = appliedDocument(event.Operation, stored.Source)
+	if err := AppendOutboxEvent(ctx, event, field); err != nil {
+		log.Warnf("Failed to append pending %s event of book %s: %v", event.Type, event.BookID, err)
+		return
+	}
+	log.Infof("Appended pending %s event of book %s to the outbox", event.Type, event.BookID)
+}
+
+// appliedTo reports whether the stored book shows the staged write: at the version the write
+// expected or later, with every field the write set.
+func (p pendingEvent) appliedTo(stored *storedDocument) bool {
+	if stored == nil || stored.Version < p.Event.Version {
+		return false
+	}
+	return documentContains(stored.Source, p.Written)
+}
+
+// documentContains reports whether a document holds the given fields, comparing nested objects field by field
+// the way a partial update merges them.
+func documentContains(document, fields map[string]interface{}) bool {
+	for field, value := range fields {
+		nested, isObject := value.(map[string]interface{})
+		current, wasObject := document[field].(map[string]interface{})
+		if isObject && wasObject {
+			if !documentContains(current, nested) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(document[field], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// outboxPendingKey holds the events staged before their write, by "<tenant>:<book id>:<version>:<event id>",
+// until the event is appended to the outbox
+func outboxPendingKey() string {
+	return redisKey("outbox:pending")
+}
+
+func outboxPendingLockKey() string {
+	return redisKey("outbox:pending:lock")
+}
@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetIn   time.Duration
+}
+
+// slidingWindowScript keeps one sorted set entry per accepted request, scored by its timestamp.
+// It returns whether the request was accepted, the requests in the window and the oldest score.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+  redis.call('ZADD', key, now, ARGV[4])
+  count = count + 1
+  allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')[2] or now
+return {allowed, count, tostring(oldest)}
+`)
+
+// AllowRequest applies a sliding window limit shared by every replica through Redis.
+func AllowRequest(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	if redisClient == nil {
+		return RateLimitResult{}, errors.New("redis client not initialized")
+	}
+
+	now := time.Now().UnixMilli()
+	raw, err := slidingWindowScript.Run(ctx, redisClient, []string{redisKey(key)},
+		now, window.Milliseconds(), limit, strconv.FormatInt(now, 10)+"-"+uuid.NewString(),
+	).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	allowed, _ := raw[0].(int64)
+	count, _ := raw[1].(int64)
+	oldestStr, _ := raw[2].(string)
+	oldest, _ := strconv.ParseFloat(oldestStr, 64)
+
+	resetIn := time.Duration(int64(oldest)+window.Milliseconds()-now) * time.Millisecond
+	return RateLimitResult{
+		Allowed:   allowed == 1,
+		Limit:     limit,
+		Remaining: max(limit-int(count), 0),
+		ResetIn:   max(resetIn, 0),
+	}, nil
+}
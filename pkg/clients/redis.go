@@ -1,11 +1,9 @@
 package clients
 
 import (
-	"book_service/pkg/consts"
+	"book_service/pkg/models/common"
 	"book_service/pkg/utils"
 	"context"
-	"encoding/json"
-	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -13,20 +11,35 @@ import (
 )
 
 type UserAction struct {
-	Method string    `json:"method"`
-	Path   string    `json:"path"`
-	Time   time.Time `json:"time"`
-	User   string    `json:"user"`
+	ID            string                        `json:"id,omitempty"`
+	Method        string                        `json:"method"`
+	Path          string                        `json:"path"`
+	Route         string                        `json:"route,omitempty"`
+	Time          time.Time                     `json:"time"`
+	User          string                        `json:"user"`
+	Tenant        string                        `json:"tenant,omitempty"`
+	Status        int                           `json:"status"`
+	ErrorCategory string                        `json:"error_category,omitempty"`
+	LatencyMs     int64                         `json:"latency_ms"`
+	RequestID     string                        `json:"request_id,omitempty"`
+	BookID        string                        `json:"book_id,omitempty"`
+	Changes       map[string]common.FieldChange `json:"changes,omitempty"`
+	// SampleRate is set when only part of the group's successful requests are recorded
+	SampleRate float64 `json:"sample_rate,omitempty"`
 }
 
-var (
-	redisClient   *redis.Client
-	actionBuffers = make(map[string][]UserAction)
-	bufferMutex   sync.Mutex
-	actionsChan   = make(chan UserAction, consts.ActionsChanelSize)
-	flushInterval = consts.FlushInterval
-	flushSize     = consts.FlushSize
-)
+var redisClient *redis.Client
+
+// redisKey namespaces a Redis key as <INDEX_PREFIX>-<INDEX_SUFFIX>:<key>, so deployments sharing a Redis
+// keep apart the way their indices do. Without a prefix or suffix the key is left as is.
+func redisKey(key string) string {
+	prefix, _ := utils.GetEnvVar[string]("INDEX_PREFIX", "")
+	suffix, _ := utils.GetEnvVar[string]("INDEX_SUFFIX", "")
+	if namespace := utils.FormatIndexName(prefix, suffix); namespace != "" {
+		return namespace + ":" + key
+	}
+	return key
+}
 
 func InitRedisClient() {
 	redisUri, _ := utils.GetEnvVar[string]("REDIS_URI", "localhost:6379")
@@ -40,104 +53,12 @@ func InitRedisClient() {
 	}
 	log.Println("Connected to Redis")
 
-	go actionWorker()
-}
-
-func AppendAction(action UserAction) {
-	select {
-	case actionsChan <- action:
-	default:
-		log.Println("Warning: actions channel is full, dropping action")
-	}
-}
-
-func GetLastActions(user string) ([]UserAction, error) {
-	vals, err := redisClient.LRange(context.Background(), "user:"+user+":actions", 0, -1).Result()
-	if err != nil {
-		log.Errorf("Was unable to get user action for %s with error: %v", user, err)
-		return nil, err
-	}
-
-	var actions []UserAction
-	var userAction UserAction
-	for _, val := range vals {
-		err := json.Unmarshal([]byte(val), &userAction)
-		if err != nil {
-			log.Errorf("Was unable to unmarshal actions for %s with error: %v", user, err)
-			return nil, err
-		}
-		actions = append(actions, userAction)
-	}
-
-	return actions, nil
-}
-
-func ShutDownRedisClient() {
-	close(actionsChan)
-}
-
-func actionWorker() {
-	ticker := time.NewTicker(flushInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case action, ok := <-actionsChan:
-			if !ok {
-				flushAllToRedis()
-				return
-			}
-			addToBuffer(action)
-		case <-ticker.C:
-			flushAllToRedis()
-		}
-	}
-}
-
-func addToBuffer(action UserAction) {
-	bufferMutex.Lock()
-	defer bufferMutex.Unlock()
-
-	buffer, exists := actionBuffers[action.User]
-	if !exists {
-		buffer = []UserAction{}
-	}
-	buffer = append(buffer, action)
-	actionBuffers[action.User] = buffer
-
-	if len(buffer) >= flushSize {
-		flushAllToRedis()
-	}
-}
-
-func flushAllToRedis() {
-	bufferMutex.Lock()
-	defer bufferMutex.Unlock()
-
-	if len(actionBuffers) == 0 {
-		return
-	}
-
-	pipe := redisClient.Pipeline()
-	for user, actions := range actionBuffers {
-		flushUserActionsToPipeline(pipe, user, actions)
-		delete(actionBuffers, user)
-	}
-
-	_, err := pipe.Exec(context.Background())
-	if err != nil {
-		log.Printf("Failed to execute pipeline: %v", err)
-	}
+	startActionPipeline()
 }
 
-func flushUserActionsToPipeline(pipe redis.Pipeliner, user string, actions []UserAction) {
-	for _, action := range actions {
-		data, err := json.Marshal(action)
-		if err != nil {
-			log.Printf("Failed to marshal action for user %s: %v", user, err)
-			continue
-		}
-		pipe.LPush(context.Background(), "user:"+user+":actions", data)
-		pipe.LTrim(context.Background(), "user:"+user+":actions", 0, 2) // Keep only the 3 most recent elements
-	}
+// UseRedisClient swaps the Redis client, e.g. for one on a test server, and returns the one it replaced.
+func UseRedisClient(client *redis.Client) *redis.Client {
+	previous := redisClient
+	redisClient = client
+	return previous
 }
@@ -0,0 +1,137 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	m "book_service/pkg/models/common"
+	"book_service/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// BookRevision is a change applied to a book. Snapshot is the whole book after it,
+// empty for a deletion, and Changes the fields it changed.
+type BookRevision struct {
+	Revision  int64                    `json:"revision"`
+	BookID    string                   `json:"book_id"`
+	Operation string                   `json:"operation"`
+	Version   int64                    `json:"version"`
+	Snapshot  map[string]interface{}   `json:"snapshot,omitempty"`
+	Changes   map[string]m.FieldChange `json:"changes,omitempty"`
+	Actor     string                   `json:"actor,omitempty"`
+	RequestID string                   `json:"request_id,omitempty"`
+	CreatedAt time.Time                `json:"created_at"`
+}
+
+// RevisionPage is a page of revisions; NextBefore continues it and is 0 on the last page.
+type RevisionPage struct {
+	Revisions  []BookRevision `json:"revisions"`
+	NextBefore int64          `json:"next_before,omitempty"`
+}
+
+// RecordRevision keeps an applied change of a book, up to consts.MaxBookRevisions per book. Revisions are
+// numbered by the Elasticsearch version the change produced, so they follow the order the writes were
+// applied in whichever worker records them first.
+func RecordRevision(ctx context.Context, event BookEvent) (BookRevision, error) {
+	if redisClient == nil {
+		return BookRevision{}, errors.New("redis client not initialized")
+	}
+	if event.Version <= 0 {
+		return BookRevision{}, errors.New("revision without a version")
+	}
+
+	revision := BookRevision{
+		Revision:  event.Version,
+		BookID:    event.BookID,
+		Operation: event.Operation,
+		Version:   event.Version,
+		Snapshot:  event.After,
+		Changes:   utils.DiffFields(event.Before, event.After),
+		Actor:     event.Actor,
+		RequestID: event.RequestID,
+		CreatedAt: event.OccurredAt,
+	}
+	data, err := json.Marshal(revision)
+	if err != nil {
+		return BookRevision{}, err
+	}
+
+	key := revisionsKey(event.Tenant, event.BookID)
+	score := strconv.FormatInt(revision.Revision, 10)
+	pipe := redisClient.TxPipeline()
+	// A revision recorded again replaces the earlier copy
+	pipe.ZRemRangeByScore(ctx, key, score, score)
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(revision.Revision), Member: data})
+	pipe.ZRemRangeByRank(ctx, key, 0, -consts.MaxBookRevisions-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return BookRevision{}, fmt.Errorf("saving revision: %w", err)
+	}
+	return revision, nil
+}
+
+// ListRevisions pages the revisions of a book newest first, starting below the revision before (0 for the latest).
+func ListRevisions(ctx context.Context, tenant, bookID string, before int64, limit int) (RevisionPage, error) {
+	if redisClient == nil {
+		return RevisionPage{}, errors.New("redis client not initialized")
+	}
+
+	max := "+inf"
+	if before > 0 {
+		max = "(" + strconv.FormatInt(before, 10)
+	}
+	entries, err := redisClient.ZRevRangeByScore(ctx, revisionsKey(tenant, bookID), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   max,
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return RevisionPage{}, fmt.Errorf("listing revisions of book %s: %w", bookID, err)
+	}
+
+	revisions := make([]BookRevision, 0, len(entries))
+	for _, entry := range entries {
+		var revision BookRevision
+		if err := json.Unmarshal([]byte(entry), &revision); err != nil {
+			return RevisionPage{}, fmt.Errorf("decoding revision of book %s: %w", bookID, err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	page := RevisionPage{Revisions: revisions}
+	if len(revisions) == limit {
+		page.NextBefore = revisions[len(revisions)-1].Revision
+	}
+	return page, nil
+}
+
+func GetRevision(ctx context.Context, tenant, bookID string, number int64) (BookRevision, error) {
+	if redisClient == nil {
+		return BookRevision{}, errors.New("redis client not initialized")
+	}
+
+	score := strconv.FormatInt(number, 10)
+	entries, err := redisClient.ZRangeByScore(ctx, revisionsKey(tenant, bookID), &redis.ZRangeBy{Min: score, Max: score}).Result()
+	if err != nil {
+		return BookRevision{}, fmt.Errorf("reading revision %d of book %s: %w", number, bookID, err)
+	}
+	if len(entries) == 0 {
+		return BookRevision{}, ErrRevisionNotFound
+	}
+
+	var revision BookRevision
+	if err := json.Unmarshal([]byte(entries[0]), &revision); err != nil {
+		return BookRevision{}, fmt.Errorf("decoding revision %d of book %s: %w", number, bookID, err)
+	}
+	return revision, nil
+}
+
+func revisionsKey(tenant, bookID string) string {
+	return redisKey("revisions:" + tenant + ":book:" + bookID)
+}
@@ -0,0 +1,120 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// OutboxSink receives outbox events in stream order. Publish must fail unless every event was handed
+// over; the batch is then retried, so a sink sees each event at least once and should dedupe on BookEvent.ID.
+type OutboxSink interface {
+	Name() string
+	Publish(ctx context.Context, events []BookEvent) error
+}
+
+type OutboxSinkFactory func() (OutboxSink, error)
+
+var (
+	outboxSinksMutex sync.Mutex
+	outboxSinks      = map[string]OutboxSinkFactory{
+		consts.SinkWebhooks: func() (OutboxSink, error) { return webhookSink{}, nil },
+		consts.SinkFile: func() (OutboxSink, error) {
+			path, _ := utils.GetEnvVar[string]("OUTBOX_FILE_PATH", consts.DefaultOutboxFile)
+			return NewFileSink(path)
+		},
+		consts.SinkStdout: func() (OutboxSink, error) { return NewWriterSink(consts.SinkStdout, os.Stdout), nil },
+	}
+)
+
+// RegisterOutboxSink makes a sink selectable in OUTBOX_SINKS. Call it before StartOutboxRelay.
+func RegisterOutboxSink(name string, factory OutboxSinkFactory) {
+	outboxSinksMutex.Lock()
+	defer outboxSinksMutex.Unlock()
+	outboxSinks[name] = factory
+}
+
+func newOutboxSink(name string) (OutboxSink, error) {
+	outboxSinksMutex.Lock()
+	factory, ok := outboxSinks[name]
+	outboxSinksMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown outbox sink %q", name)
+	}
+	return factory()
+}
+
+// webhookSink schedules deliveries to the subscribed webhooks, which retry on their own.
+type webhookSink struct{}
+
+func (webhookSink) Name() string {
+	return consts.SinkWebhooks
+}
+
+func (webhookSink) Publish(ctx context.Context, events []BookEvent) error {
+	for _, event := range events {
+		if err := PublishBookEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writerSink writes events as NDJSON. Backed by a file, it fsyncs every batch and owns the file.
+type writerSink struct {
+	name  string
+	mutex sync.Mutex
+	w     io.Writer
+	file  *os.File
+}
+
+// NewWriterSink writes every event as a JSON line to w.
+func NewWriterSink(name string, w io.Writer) OutboxSink {
+	return &writerSink{name: name, w: w}
+}
+
+// NewFileSink appends events as JSON lines to the file at path, fsyncing every batch.
+func NewFileSink(path string) (OutboxSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening outbox file: %w", err)
+	}
+	return &writerSink{name: consts.SinkFile, w: file, file: file}, nil
+}
+
+func (s *writerSink) Name() string {
+	return s.name
+}
+
+func (s *writerSink) Publish(_ context.Context, events []BookEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	buffered := bufio.NewWriter(s.w)
+	encoder := json.NewEncoder(buffered)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	if s.file != nil {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+func (s *writerSink) Close() error {
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
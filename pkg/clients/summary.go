@@ -0,0 +1,99 @@
+package clients
+
+import (
+	"sort"
+	"time"
+)
+
+type BookCount struct {
+	BookID string `json:"book_id"`
+	Count  int    `json:"count"`
+}
+
+// ActivitySummary describes what a user did in a time window. Actions are keyed by method and route.
+type ActivitySummary struct {
+	User     string         `json:"user"`
+	Total    int            `json:"total"`
+	Actions  map[string]int `json:"actions"`
+	TopBooks []BookCount    `json:"top_books"`
+	LastSeen time.Time      `json:"last_seen"`
+}
+
+type userActivity struct {
+	summary ActivitySummary
+	books   map[string]int
+}
+
+// ActivitySummarizer accumulates actions into one ActivitySummary per user.
+type ActivitySummarizer struct {
+	topBooks int
+	users    map[string]*userActivity
+}
+
+func NewActivitySummarizer(topBooks int) *ActivitySummarizer {
+	return &ActivitySummarizer{topBooks: topBooks, users: make(map[string]*userActivity)}
+}
+
+func (s *ActivitySummarizer) Add(action UserAction) {
+	activity, ok := s.users[action.User]
+	if !ok {
+		activity = &userActivity{
+			summary: ActivitySummary{User: action.User, Actions: make(map[string]int)},
+			books:   make(map[string]int),
+		}
+		s.users[action.User] = activity
+	}
+
+	activity.summary.Total++
+	activity.summary.Actions[ActionType(action)]++
+	if action.BookID != "" {
+		activity.books[action.BookID]++
+	}
+	if action.Time.After(activity.summary.LastSeen) {
+		activity.summary.LastSeen = action.Time
+	}
+}
+
+// Summaries returns the users ordered by their last action, most recent first.
+func (s *ActivitySummarizer) Summaries() []ActivitySummary {
+	summaries := make([]ActivitySummary, 0, len(s.users))
+	for _, activity := range s.users {
+		summary := activity.summary
+		summary.TopBooks = topBooks(activity.books, s.topBooks)
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].LastSeen.Equal(summaries[j].LastSeen) {
+			return summaries[i].User < summaries[j].User
+		}
+		return summaries[i].LastSeen.After(summaries[j].LastSeen)
+	})
+	return summaries
+}
+
+// ActionType names an action by its method and route template, e.g. "PUT /api/v1/books/:id".
+// Requests that matched no route fall back to their path.
+func ActionType(action UserAction) string {
+	route := action.Route
+	if route == "" {
+		route = action.Path
+	}
+	return action.Method + " " + route
+}
+
+func topBooks(books map[string]int, limit int) []BookCount {
+	counts := make([]BookCount, 0, len(books))
+	for bookID, count := range books {
+		counts = append(counts, BookCount{BookID: bookID, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count == counts[j].Count {
+			return counts[i].BookID < counts[j].BookID
+		}
+		return counts[i].Count > counts[j].Count
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
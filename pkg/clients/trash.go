@@ -0,0 +1,217 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common"
+	"book_service/pkg/utils"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrNotInTrash       = errors.New("book not in trash")
+)
+
+var (
+	trashPurgerStop context.CancelFunc
+	trashPurger     sync.WaitGroup
+)
+
+// GetTrashedBook returns a book of the tenant behind ctx that is in the trash.
+func GetTrashedBook(ctx context.Context, id string) (map[string]interface{}, error) {
+	book, err := getDocument(ctx, booksIndexFor(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil || book[consts.DeletedAtField] == nil {
+		return nil, ErrNotInTrash
+	}
+	return book, nil
+}
+
+// TrashRetention is how long a deleted book stays in the trash before it is purged.
+func TrashRetention() time.Duration {
+	retention, _ := utils.GetEnvVar[time.Duration]("TRASH_RETENTION", consts.DefaultTrashRetention)
+	return retention
+}
+
+// StartTrashPurger purges the books that outstayed TrashRetention every TRASH_PURGE_INTERVAL.
+// Needs the index worker pool, which applies the purges.
+func StartTrashPurger() {
+	if redisClient == nil {
+		return
+	}
+	interval, _ := utils.GetEnvVar[time.Duration]("TRASH_PURGE_INTERVAL", consts.DefaultTrashPurgeEvery)
+	ctx, cancel := context.WithCancel(context.Background())
+	trashPurgerStop = cancel
+
+	trashPurger.Add(1)
+	go func() {
+		defer trashPurger.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := purgeExpiredTrash(ctx, interval)
+				if err != nil {
+					log.Warnf("Failed to purge the trash: %v", err)
+				} else if purged > 0 {
+					log.Infof("Queued %d expired books from the trash for purging", purged)
+				}
+			}
+		}
+	}()
+}
+
+// StopTrashPurger stops purging; call it before the worker pool shuts down.
+func StopTrashPurger() {
+	if trashPurgerStop == nil {
+		return
+	}
+	trashPurgerStop()
+	trashPurger.Wait()
+}
+
+// claimTrashScript takes due books off the trash in one step, so concurrent purgers never
+// queue the same book twice. It returns the claimed members with their scores.
+var claimTrashScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, ARGV[2])
+for i = 1, #due, 2 do
+	redis.call('ZREM', KEYS[1], due[i])
+end
+return due
+`)
+
+// purgeExpiredTrash queues the purge of expired books. One replica purges per interval;
+// books whose purge fails are put back in the trash by the worker and picked up by a later run.
+func purgeExpiredTrash(ctx context.Context, interval time.Duration) (int, error) {
+	locked, err := redisClient.SetNX(ctx, trashPurgeLockKey(), outboxInstance, interval/2).Result()
+	if err != nil || !locked {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-TrashRetention()).UnixMilli()
+	queued := 0
+	for ctx.Err() == nil {
+		due, err := claimTrashScript.Run(ctx, redisClient, []string{trashDueKey()}, cutoff, consts.TrashPurgeBatchSize).StringSlice()
+		if err != nil {
+			return queued, err
+		}
+		for i := 0; i+1 < len(due); i += 2 {
+			tenant, id, _ := strings.Cut(due[i], ":")
+			score, _ := strconv.ParseFloat(due[i+1], 64)
+			deletedAt := time.UnixMilli(int64(score)).UTC().Format(time.RFC3339)
+			enqueueIndexRequest(IndexRequest{
+				Ctx:          context.Background(),
+				Tenant:       tenant,
+				Index:        TenantIndexName(consts.BooksIndex, tenant),
+				ID:           id,
+				Document:     common.BookDeletion{DeletedAt: &deletedAt},
+				ResponseChan: make(chan *IndexResult, 1),
+				Function:     consts.DoPurgeIndex,
+			})
+			queued++
+		}
+		if len(due) < 2*consts.TrashPurgeBatchSize {
+			break
+		}
+	}
+	return queued, nil
+}
+
+// returnToTrash puts back a book whose purge failed, so a later run purges it.
+func returnToTrash(req IndexRequest) {
+	member := req.Tenant + ":" + req.ID
+	err := redisClient.ZAddNX(context.Background(), trashDueKey(), &redis.Z{Score: float64(deletedAt(req).UnixMilli()), Member: member}).Err()
+	if err != nil {
+		log.Errorf("Failed to put book %s back in the trash: %v", req.ID, err)
+	}
+}
+
+// MergedInto is the book a book of the tenant behind ctx was merged into, empty when it was not merged.
+// The redirect outlives the merged book's stay in the trash; books merged before it was kept in Redis
+// are looked up in the trash.
+func MergedInto(ctx context.Context, id string) (string, error) {
+	if redisClient != nil {
+		survivor, err := redisClient.Get(ctx, mergedKey(TenantFromContext(ctx), id)).Result()
+		if err == nil {
+			return survivor, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			return "", err
+		}
+	}
+
+	book, err := GetTrashedBook(ctx, id)
+	if errors.Is(err, ErrNotInTrash) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	survivor, _ := book[consts.MergedIntoField].(string)
+	return survivor, nil
+}
+
+// updateTrash tracks when books entered the trash, and forgets them once they leave it.
+func updateTrash(ctx context.Context, req IndexRequest) error {
+	member := req.Tenant + ":" + req.ID
+	switch req.Function {
+	case consts.DoDeleteIndex:
+		pipe := redisClient.TxPipeline()
+		pipe.ZAdd(ctx, trashDueKey(), &redis.Z{Score: float64(deletedAt(req).UnixMilli()), Member: member})
+		if deletion, ok := req.Document.(common.BookDeletion); ok && deletion.MergedInto != nil {
+			pipe.Set(ctx, mergedKey(req.Tenant, req.ID), *deletion.MergedInto, 0)
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	case consts.DoUndeleteIndex, consts.DoRestoreIndex:
+		pipe := redisClient.TxPipeline()
+		pipe.ZRem(ctx, trashDueKey(), member)
+		pipe.Del(ctx, mergedKey(req.Tenant, req.ID))
+		_, err := pipe.Exec(ctx)
+		return err
+	case consts.DoPurgeIndex:
+		pipe := redisClient.TxPipeline()
+		pipe.ZRem(ctx, trashDueKey(), member)
+		pipe.Del(ctx, revisionsKey(req.Tenant, req.ID))
+		_, err := pipe.Exec(ctx)
+		return err
+	}
+	return nil
+}
+
+// mergedKey holds the survivor a merged book redirects to; unlike the book, it is not purged
+func mergedKey(tenant, id string) string {
+	return redisKey("merged:" + tenant + ":" + id)
+}
+
+func deletedAt(req IndexRequest) time.Time {
+	if deletion, ok := req.Document.(common.BookDeletion); ok && deletion.DeletedAt != nil {
+		if at, err := time.Parse(time.RFC3339, *deletion.DeletedAt); err == nil {
+			return at
+		}
+	}
+	return time.Now()
+}
+
+// trashDueKey scores every trashed book, as "<tenant>:<id>", by the time it was deleted
+func trashDueKey() string {
+	return redisKey("trash:due")
+}
+
+func trashPurgeLockKey() string {
+	return redisKey("trash:purge:lock")
+}
@@ -0,0 +1,481 @@
+package clients
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// BookEvent is a change applied to a book, as appended to the outbox and delivered to its sinks.
+// Before is empty for creates, After for deletes. Version is the Elasticsearch document version.
+type BookEvent struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Operation  string                 `json:"operation"`
+	Tenant     string                 `json:"tenant,omitempty"`
+	BookID     string                 `json:"book_id"`
+	Before     map[string]interface{} `json:"before,omitempty"`
+	After      map[string]interface{} `json:"after,omitempty"`
+	Version    int64                  `json:"version"`
+	Actor      string                 `json:"actor,omitempty"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// Webhook is a subscription to book events. No Events means every event, no Tenant every tenant.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (w Webhook) Accepts(event BookEvent) bool {
+	if w.Tenant != "" && w.Tenant != event.Tenant {
+		return false
+	}
+	return len(w.Events) == 0 || lo.Contains(w.Events, event.Type)
+}
+
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	WebhookID      string     `json:"webhook_id"`
+	Event          BookEvent  `json:"event"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	RedeliveryOf   string     `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CreateWebhook registers a subscription. An empty secret gets a random one.
+func CreateWebhook(ctx context.Context, webhook Webhook) (Webhook, error) {
+	if redisClient == nil {
+		return Webhook{}, errors.New("redis client not initialized")
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return Webhook{}, fmt.Errorf("generating webhook secret: %w", err)
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	webhook.ID = uuid.NewString()
+	webhook.CreatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(webhook)
+	if err != nil {
+		return Webhook{}, err
+	}
+	pipe := redisClient.TxPipeline()
+	pipe.Set(ctx, webhookKey(webhook.ID), data, 0)
+	pipe.SAdd(ctx, webhooksKey(), webhook.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Webhook{}, fmt.Errorf("saving webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func GetWebhook(ctx context.Context, id string) (Webhook, error) {
+	if redisClient == nil {
+		return Webhook{}, errors.New("redis client not initialized")
+	}
+
+	data, err := redisClient.Get(ctx, webhookKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	if err != nil {
+		return Webhook{}, fmt.Errorf("reading webhook %s: %w", id, err)
+	}
+
+	var webhook Webhook
+	if err := json.Unmarshal(data, &webhook); err != nil {
+		return Webhook{}, fmt.Errorf("decoding webhook %s: %w", id, err)
+	}
+	return webhook, nil
+}
+
+func ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	if redisClient == nil {
+		return nil, errors.New("redis client not initialized")
+	}
+
+	ids, err := redisClient.SMembers(ctx, webhooksKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing webhooks: %w", err)
+	}
+
+	webhooks := make([]Webhook, 0, len(ids))
+	for _, id := range ids {
+		webhook, err := GetWebhook(ctx, id)
+		if errors.Is(err, ErrWebhookNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a subscription; its pending deliveries fail when they come due.
+func DeleteWebhook(ctx context.Context, id string) (Webhook, error) {
+	webhook, err := GetWebhook(ctx, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	pipe := redisClient.TxPipeline()
+	pipe.Del(ctx, webhookKey(id), webhookDeliveryLogKey(id))
+	pipe.SRem(ctx, webhooksKey(), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Webhook{}, fmt.Errorf("deleting webhook %s: %w", id, err)
+	}
+	return webhook, nil
+}
+
+// PublishBookEvent schedules a delivery of event to every webhook subscribed to it.
+func PublishBookEvent(ctx context.Context, event BookEvent) error {
+	webhooks, err := ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Accepts(event) {
+			continue
+		}
+		if _, err := scheduleDelivery(ctx, webhook.ID, event, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the latest deliveries of a webhook, newest first.
+func ListWebhookDeliveries(ctx context.Context, webhookID string) ([]WebhookDelivery, error) {
+	if _, err := GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	ids, err := redisClient.ZRevRange(ctx, webhookDeliveryLogKey(webhookID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing deliveries of webhook %s: %w", webhookID, err)
+	}
+
+	deliveries := make([]WebhookDelivery, 0, len(ids))
+	for _, id := range ids {
+		delivery, err := getDelivery(ctx, id)
+		if errors.Is(err, ErrDeliveryNotFound) {
+			// Expired past the delivery retention
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhook schedules a new delivery of the event of a past delivery.
+func RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (WebhookDelivery, error) {
+	if _, err := GetWebhook(ctx, webhookID); err != nil {
+		return WebhookDelivery{}, err
+	}
+
+	original, err := getDelivery(ctx, deliveryID)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	if original.WebhookID != webhookID {
+		return WebhookDelivery{}, ErrDeliveryNotFound
+	}
+	return scheduleDelivery(ctx, webhookID, original.Event, original.ID)
+}
+
+const (
+	// webhookClaimLease hides a claimed delivery from other dispatchers while it is attempted;
+	// if this process dies mid attempt the delivery becomes due again once it passes
+	webhookClaimLease = 3 * consts.WebhookTimeout
+)
+
+// claimDeliveriesScript picks due deliveries and pushes them past the lease in one step,
+// so concurrent dispatchers never claim the same delivery.
+var claimDeliveriesScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(due) do
+	redis.call('ZADD', KEYS[1], ARGV[3], id)
+end
+return due
+`)
+
+var (
+	webhookHTTPClient = &http.Client{Timeout: consts.WebhookTimeout}
+	webhookStop       context.CancelFunc
+	webhookWorkers    sync.WaitGroup
+)
+
+// StartWebhookDispatcher polls for due deliveries and attempts them with consts.WebhookWorkers workers.
+func StartWebhookDispatcher() {
+	ctx, cancel := context.WithCancel(context.Background())
+	webhookStop = cancel
+
+	due := make(chan string)
+	for i := 0; i < consts.WebhookWorkers; i++ {
+		webhookWorkers.Add(1)
+		go func() {
+			defer webhookWorkers.Done()
+			for id := range due {
+				attemptDelivery(ctx, id)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(due)
+		ticker := time.NewTicker(consts.WebhookPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ids, err := claimDueDeliveries(ctx)
+				if err != nil {
+					log.Warnf("Failed to claim webhook deliveries: %v", err)
+					continue
+				}
+				for _, id := range ids {
+					select {
+					case due <- id:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// StopWebhookDispatcher stops polling and waits for the attempts in flight.
+func StopWebhookDispatcher() {
+	if webhookStop == nil {
+		return
+	}
+	webhookStop()
+	webhookWorkers.Wait()
+}
+
+func claimDueDeliveries(ctx context.Context) ([]string, error) {
+	now := time.Now()
+	return claimDeliveriesScript.Run(ctx, redisClient, []string{webhookDeliveryDue()},
+		now.UnixMilli(), consts.WebhookBatchSize, now.Add(webhookClaimLease).UnixMilli(),
+	).StringSlice()
+}
+
+func attemptDelivery(ctx context.Context, id string) {
+	delivery, err := getDelivery(ctx, id)
+	if errors.Is(err, ErrDeliveryNotFound) {
+		redisClient.ZRem(ctx, webhookDeliveryDue(), id)
+		return
+	}
+	if err != nil {
+		log.Warnf("Failed to read webhook delivery %s: %v", id, err)
+		return
+	}
+
+	webhook, err := GetWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, ErrWebhookNotFound) {
+		delivery.Status = consts.DeliveryFailed
+		delivery.LastError = "webhook deleted"
+		finishDelivery(ctx, delivery)
+		return
+	}
+	if err != nil {
+		log.Warnf("Failed to read webhook %s: %v", delivery.WebhookID, err)
+		return
+	}
+
+	delivery.Attempts++
+	statusCode, err := sendWebhook(ctx, webhook, delivery)
+	delivery.LastStatusCode = statusCode
+	delivery.UpdatedAt = time.Now().UTC()
+
+	switch {
+	case err == nil:
+		delivery.Status = consts.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= consts.WebhookMaxAttempts:
+		delivery.Status = consts.DeliveryFailed
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = nil
+		log.Warnf("Webhook delivery %s to %s failed for good: %v", delivery.ID, webhook.URL, err)
+	default:
+		next := time.Now().Add(utils.RetryBackoff(delivery.Attempts, consts.WebhookRetryBase, consts.WebhookRetryMax)).UTC()
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = &next
+		if err := saveDelivery(ctx, delivery); err != nil {
+			log.Warnf("Failed to reschedule webhook delivery %s: %v", delivery.ID, err)
+		}
+		return
+	}
+	finishDelivery(ctx, delivery)
+}
+
+func sendWebhook(ctx context.Context, webhook Webhook, delivery WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "book_service-webhooks")
+	request.Header.Set(consts.WebhookEventHeader, delivery.Event.Type)
+	request.Header.Set(consts.WebhookDeliveryHeader, delivery.ID)
+	request.Header.Set(consts.WebhookSignatureHeader, utils.SignWebhookPayload(webhook.Secret, time.Now(), body))
+
+	response, err := webhookHTTPClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("endpoint responded %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+func scheduleDelivery(ctx context.Context, webhookID string, event BookEvent, redeliveryOf string) (WebhookDelivery, error) {
+	now := time.Now().UTC()
+	delivery := WebhookDelivery{
+		ID:            uuid.NewString(),
+		WebhookID:     webhookID,
+		Event:         event,
+		Status:        consts.DeliveryPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  redeliveryOf,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	logKey := webhookDeliveryLogKey(webhookID)
+	pipe := redisClient.TxPipeline()
+	pipe.Set(ctx, webhookDeliveryKey(delivery.ID), data, consts.WebhookDeliveryRetention)
+	pipe.ZAdd(ctx, logKey, &redis.Z{Score: float64(now.UnixMilli()), Member: delivery.ID})
+	pipe.ZRemRangeByRank(ctx, logKey, 0, -consts.WebhookDeliveryLogSize-1)
+	pipe.ZAdd(ctx, webhookDeliveryDue(), &redis.Z{Score: float64(now.UnixMilli()), Member: delivery.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return WebhookDelivery{}, fmt.Errorf("scheduling webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// saveDelivery stores an attempted delivery and schedules its next attempt.
+func saveDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	pipe := redisClient.TxPipeline()
+	pipe.Set(ctx, webhookDeliveryKey(delivery.ID), data, consts.WebhookDeliveryRetention)
+	pipe.ZAdd(ctx, webhookDeliveryDue(), &redis.Z{Score: float64(delivery.NextAttemptAt.UnixMilli()), Member: delivery.ID})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// finishDelivery stores a delivery that will not be attempted again.
+func finishDelivery(ctx context.Context, delivery WebhookDelivery) {
+	data, err := json.Marshal(delivery)
+	if err == nil {
+		pipe := redisClient.TxPipeline()
+		pipe.Set(ctx, webhookDeliveryKey(delivery.ID), data, consts.WebhookDeliveryRetention)
+		pipe.ZRem(ctx, webhookDeliveryDue(), delivery.ID)
+		_, err = pipe.Exec(ctx)
+	}
+	if err != nil {
+		log.Warnf("Failed to store webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+func getDelivery(ctx context.Context, id string) (WebhookDelivery, error) {
+	if redisClient == nil {
+		return WebhookDelivery{}, errors.New("redis client not initialized")
+	}
+
+	data, err := redisClient.Get(ctx, webhookDeliveryKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return WebhookDelivery{}, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("reading webhook delivery %s: %w", id, err)
+	}
+
+	var delivery WebhookDelivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return WebhookDelivery{}, fmt.Errorf("decoding webhook delivery %s: %w", id, err)
+	}
+	return delivery, nil
+}
+
+func webhookKey(id string) string {
+	return redisKey("webhook:" + id)
+}
+
+func webhookDeliveryLogKey(webhookID string) string {
+	return redisKey("webhook:" + webhookID + ":deliveries")
+}
+
+func webhookDeliveryKey(id string) string {
+	return redisKey("webhook:delivery:" + id)
+}
+
+func webhooksKey() string {
+	return redisKey("webhooks")
+}
+
+func webhookDeliveryDue() string {
+	return redisKey("webhook:deliveries:due")
+}
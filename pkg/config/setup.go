@@ -5,6 +5,7 @@ import (
 	"book_service/pkg/consts"
 	mw "book_service/pkg/middlewares"
 	"book_service/pkg/routes"
+	"errors"
 	"os"
 
 	"github.com/gin-gonic/gin"
@@ -41,7 +42,7 @@ func setupServer() *gin.Engine {
 
 	log.Infof("Setting up middlewares")
 	app.Use(gin.Recovery())
-	app.Use(mw.Logger(), mw.RecordActions())
+	app.Use(mw.RequestID(), mw.Logger(), mw.Authenticate(), mw.Tenant(), mw.RecordActions())
 
 	routes.RegisterRoutes(app)
 	log.Infof("Middlewares and routes initialized")
@@ -51,14 +52,24 @@ func setupServer() *gin.Engine {
 
 func initClients() {
 	if err := clients.InitElasticsearchClient(); err != nil {
+		if errors.Is(err, clients.ErrIncompatibleMapping) {
+			log.Fatalf("Refusing to start: %v", err)
+		}
 		log.Infof("Failed to initialize Elasticsearch: %v", err)
 	}
 
 	clients.InitRedisClient()
+	clients.StartWebhookDispatcher()
+	clients.StartOutboxRelay()
 	clients.InitElasticWorkerPool(consts.WorkersNumber)
+	clients.StartTrashPurger()
 }
 
 func shutDownClients() {
+	clients.CloseChangeStreams()
+	clients.StopTrashPurger()
 	clients.ShutdownWorkerPool(consts.WorkersNumber)
+	clients.StopOutboxRelay()
+	clients.StopWebhookDispatcher()
 	clients.ShutDownRedisClient()
 }
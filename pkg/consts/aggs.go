@@ -1,19 +1,157 @@
 package consts
 
+import "time"
+
+// Aggregation types
+const (
+	AggCardinality   = "cardinality"
+	AggValueCount    = "value_count"
+	AggAvg           = "avg"
+	AggMin           = "min"
+	AggMax           = "max"
+	AggSum           = "sum"
+	AggPercentiles   = "percentiles"
+	AggTerms         = "terms"
+	AggHistogram     = "histogram"
+	AggDateHistogram = "date_histogram"
+	AggRange         = "range"
+	AggDateRange     = "date_range"
+)
+
+// AggregationRange bounds are numbers for range and date strings for date_range; nil leaves a side open.
+type AggregationRange struct {
+	Key  string
+	From interface{}
+	To   interface{}
+}
+
+// AggregationConfig declares a single aggregation. Metric aggregations only need Field and Type,
+// bucket aggregations use the options matching their Type and may nest SubAggregations.
 type AggregationConfig struct {
 	Field string
 	Type  string
+
+	Size             int                // terms
+	Interval         float64            // histogram
+	CalendarInterval string             // date_histogram
+	TimeZone         string             // date_histogram
+	Format           string             // min, max, date_histogram, date_range
+	Percents         []float64          // percentiles
+	Ranges           []AggregationRange // range, date_range
+	MinDocCount      *int               // terms, histogram, date_histogram
+
+	SubAggregations map[string]AggregationConfig
 }
 
+const DefaultTopAuthors = 5
+
 var AggregationConfigs = map[string]map[string]AggregationConfig{
 	"BookStats": {
 		"distinct_authors": {
 			Field: "author_name.keyword",
-			Type:  "cardinality",
+			Type:  AggCardinality,
 		},
 		"total_books": {
 			Field: "_id",
-			Type:  "value_count",
+			Type:  AggValueCount,
+		},
+	},
+	"StoreStats": {
+		"distinct_authors": {
+			Field: "author_name.keyword",
+			Type:  AggCardinality,
+		},
+		"min_price": {
+			Field: "price",
+			Type:  AggMin,
+		},
+		"avg_price": {
+			Field: "price",
+			Type:  AggAvg,
+		},
+		"max_price": {
+			Field: "price",
+			Type:  AggMax,
+		},
+		"price_percentiles": {
+			Field:    "price",
+			Type:     AggPercentiles,
+			Percents: []float64{25, 50, 75, 95},
+		},
+		"ebook_available": {
+			Field: "ebook_available",
+			Type:  AggTerms,
+		},
+		"oldest_publish_date": {
+			Field:  "publish_date",
+			Type:   AggMin,
+			Format: "yyyy-MM-dd",
+		},
+		"newest_publish_date": {
+			Field:  "publish_date",
+			Type:   AggMax,
+			Format: "yyyy-MM-dd",
+		},
+		"top_authors": {
+			Field: "author_name.keyword",
+			Type:  AggTerms,
+			Size:  DefaultTopAuthors,
 		},
 	},
+	"BookDistributions": {
+		"books_per_author": {
+			Field: "author_name.keyword",
+			Type:  AggTerms,
+			Size:  10,
+			SubAggregations: map[string]AggregationConfig{
+				"avg_price": {Field: "price", Type: AggAvg},
+			},
+		},
+		"price_distribution": {
+			Field:    "price",
+			Type:     AggHistogram,
+			Interval: 50,
+		},
+		"books_per_year": {
+			Field:            "publish_date",
+			Type:             AggDateHistogram,
+			CalendarInterval: "year",
+			Format:           "yyyy",
+		},
+	},
+}
+
+// Time series metrics, the bucket doc count is always reported
+const (
+	MetricCount      = "count"
+	MetricAvgPrice   = "avg_price"
+	MetricEbookCount = "ebook_count"
+)
+
+var TimeSeriesMetrics = map[string]AggregationConfig{
+	MetricAvgPrice: {
+		Field: "price",
+		Type:  AggAvg,
+	},
+	MetricEbookCount: {
+		// Booleans aggregate as 0 and 1
+		Field: "ebook_available",
+		Type:  AggSum,
+	},
+}
+
+// TimeSeriesMaxSpans caps the from/to window of the fine grained intervals.
+// Empty buckets are returned too, so an open window could exceed search.max_buckets.
+var TimeSeriesMaxSpans = map[string]time.Duration{
+	"day":  366 * 24 * time.Hour,
+	"week": 10 * 366 * 24 * time.Hour,
+}
+
+// IsBucketAggregation reports whether an aggregation type produces buckets rather than a single value.
+func IsBucketAggregation(aggType string) bool {
+	switch aggType {
+	case AggTerms, AggHistogram, AggDateHistogram, AggRange, AggDateRange:
+		return true
+	}
+	return false
 }
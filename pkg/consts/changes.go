@@ -0,0 +1,15 @@
+package consts
+
+import "time"
+
+// Change stream config
+const (
+	// DefaultChangesRetention is how long the outbox keeps entries for change stream clients to resume from
+	DefaultChangesRetention = 1 * time.Hour
+	ChangesPollInterval     = 1 * time.Second
+	ChangesHeartbeat        = 15 * time.Second
+	ChangesBatchSize        = 100
+	ChangesRetryMs          = 3000
+	ChangesResetEvent       = "reset"
+	LastEventIDHeader       = "Last-Event-ID"
+)
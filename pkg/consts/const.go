@@ -1,7 +1,6 @@
 package consts
 
 import (
-	"math"
 	"time"
 )
 
@@ -12,11 +11,15 @@ const (
 	DoCreateIndex Function = 0
 	DoUpdateIndex Function = 1
 	DoDeleteIndex Function = 2
+	// DoRestoreIndex replaces a book, or recreates it, with a past revision
+	DoRestoreIndex Function = 3
+	// DoUndeleteIndex takes a book out of the trash, DoPurgeIndex removes it for good
+	DoUndeleteIndex Function = 4
+	DoPurgeIndex    Function = 5
+	// DoMergeIndex updates a survivor and trashes its duplicates as one task
+	DoMergeIndex Function = 6
 )
 
-// HighestBookPrice Book max price
-var HighestBookPrice = math.Inf(1)
-
 // Elasticsearch config
 const (
 	MaxIdleConnections        = 50
@@ -29,17 +32,176 @@ const (
 	TLSHandshakeTimeout   = 10 * time.Second
 	ExpectContinueTimeout = 1 * time.Second
 	WorkersNumber         = 10
+
+	// ScanBatchSize documents are read per scroll page, the scroll being kept open ScanKeepAlive between pages
+	ScanBatchSize = 1000
+	ScanKeepAlive = 1 * time.Minute
 )
 
 // ActionRoute routes
 const ActionRoute = "activity"
 
+// AdminRoute Admin routes
+const (
+	AdminRoute       = "admin"
+	AdminTokenHeader = "X-Admin-Token"
+)
+
 // ValidatedAccess Validations
 const ValidatedAccess = "validated"
 
+// TenantKey Tenants
+const (
+	TenantKey           = "tenant"
+	DefaultTenantHeader = "X-Tenant-ID"
+	// UnknownTenantCacheTTL is how long a replica keeps answering 404 for a tenant provisioned elsewhere
+	UnknownTenantCacheTTL = 30 * time.Second
+	TenantCacheSize       = 1000
+)
+
 // Redis config
 const (
 	FlushSize         = 100
 	FlushInterval     = 5 * time.Second
 	ActionsChanelSize = 1000
+	// MaxPendingActions bounds the actions kept in memory for a retry while Redis is unreachable
+	MaxPendingActions = 10000
+)
+
+// ActionsOverflowSpill What happens to actions arriving while the actions channel is full
+const (
+	ActionsOverflowSpill = "spill"
+	ActionsOverflowBlock = "block"
+	ActionsOverflowDrop  = "drop"
+
+	DefaultActionsBlockTimeout  = 2 * time.Second
+	DefaultActionsSpillMaxBytes = 64 << 20
+	ActionsShutdownTimeout      = 10 * time.Second
 )
+
+// RequestIDHeader Audit trail
+const (
+	RequestIDHeader = "X-Request-ID"
+	RequestIDKey    = "request_id"
+	AuditGroupKey   = "audit_group"
+	AuditBookKey    = "audit_book"
+	AuditChangesKey = "audit_changes"
+
+	AuditRetentionCount = 100000
+	AuditRetentionAge   = 30 * 24 * time.Hour
+
+	// AllUsers is the activity user filter an admin passes to read every user's actions
+	AllUsers = "*"
+
+	DefaultActivityLimit   = 50
+	MaxActivityLimit       = 500
+	ExportNDJSON           = "ndjson"
+	ExportCSV              = "csv"
+	DefaultSummaryTopBooks = 5
+
+	// ActivityScanLimit bounds the entries a filtered page reads before handing back a cursor
+	ActivityScanLimit = 5000
+)
+
+// AuditSampleRates Share of successful requests recorded per route group, overridden by AUDIT_SAMPLE_<GROUP>.
+// Failed requests are always recorded unless the rate is 0, which opts the group out.
+// Routes without a group are recorded in full.
+var AuditSampleRates = map[string]float64{
+	RouteSearch:   1,
+	RouteRead:     1,
+	RouteWrite:    1,
+	RouteActivity: 0,
+	RouteHealth:   0,
+	AdminRoute:    1,
+}
+
+// ErrorValidation Error categories of recorded actions
+const (
+	ErrorValidation   = "validation"
+	ErrorUnauthorized = "unauthorized"
+	ErrorForbidden    = "forbidden"
+	ErrorNotFound     = "not_found"
+	ErrorConflict     = "conflict"
+	ErrorRateLimited  = "rate_limited"
+	ErrorClient       = "client_error"
+	ErrorUnavailable  = "unavailable"
+	ErrorServer       = "server_error"
+)
+
+// Cache config
+const (
+	CacheHeader   = "X-Cache"
+	BookCacheTTL  = 5 * time.Minute
+	StatsCacheTTL = 1 * time.Minute
+	// CacheRefreshDelay covers the Elasticsearch refresh interval before a write becomes searchable
+	CacheRefreshDelay = 1500 * time.Millisecond
+)
+
+// RouteSearch Route groups for access control and rate limiting, and user tiers
+const (
+	RouteSearch = "search"
+	RouteRead   = "read"
+	RouteWrite  = "write"
+	// RouteActivity and RouteHealth are not rate limited
+	RouteActivity = "activity"
+	RouteHealth   = "health"
+
+	TierKey       = "tier"
+	TierAnonymous = "anonymous"
+	TierUser      = "user"
+)
+
+// RateLimits Default limits per route group and tier, as <requests>/<window>
+var RateLimits = map[string]map[string]string{
+	RouteSearch: {
+		TierAnonymous: "30/1m",
+		TierUser:      "120/1m",
+	},
+	RouteRead: {
+		TierAnonymous: "60/1m",
+		TierUser:      "300/1m",
+	},
+	RouteWrite: {
+		TierAnonymous: "10/1m",
+		TierUser:      "60/1m",
+	},
+}
+
+// PrincipalKey Authentication
+const (
+	PrincipalKey = "principal"
+	APIKeyHeader = "X-API-Key"
+)
+
+// APIKeyCacheTTL Managed API keys are cached in process, so a revocation takes up to this long on other replicas
+const (
+	APIKeyCacheTTL      = 30 * time.Second
+	APIKeyCacheSize     = 1000
+	APIKeyTouchInterval = 1 * time.Minute
+	APIKeyScopeAdmin    = AdminRoute
+)
+
+// APIKeyScopes Scopes an API key can be limited to; a key without scopes may call every route
+var APIKeyScopes = []string{RouteSearch, RouteRead, RouteWrite, RouteActivity, APIKeyScopeAdmin}
+
+// AnonymousAccess Default anonymous access per route group, overridden by AUTH_ANONYMOUS_<GROUP>
+var AnonymousAccess = map[string]bool{
+	RouteSearch:   true,
+	RouteRead:     true,
+	RouteWrite:    false,
+	RouteActivity: false,
+}
+
+// RoleReader Roles, each granting everything the roles below it do
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// RoleLevels ranks the roles; principals without a known role are readers
+var RoleLevels = map[string]int{
+	RoleReader: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
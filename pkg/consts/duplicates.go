@@ -0,0 +1,33 @@
+package consts
+
+// Duplicate check modes of book creation, set with DUPLICATES_MODE
+const (
+	DuplicatesReject = "reject"
+	DuplicatesWarn   = "warn"
+	DuplicatesOff    = "off"
+)
+
+// Why two books are considered duplicates
+const (
+	DuplicateByISBN    = "isbn"
+	DuplicateByTitle   = "title"
+	DuplicateBySimilar = "similar_title"
+)
+
+// Duplicate check config
+const (
+	ISBNField = "isbn"
+	// DuplicateCandidates bounds the books a new one is compared with
+	DuplicateCandidates = 20
+)
+
+// Merge config
+const (
+	MergedIntoField    = "merged_into"
+	MaxMergeDuplicates = 50
+	// MergedFromChange is the pseudo field listing the merged books in the audit trail of a merge
+	MergedFromChange = "merged_from"
+)
+
+// MergeFields are the book fields a merge picks a value of among the merged books
+var MergeFields = []string{"title", "author_name", "price", "ebook_available", "publish_date", ISBNField}
@@ -0,0 +1,53 @@
+package consts
+
+import "strconv"
+
+// Facet names
+const (
+	FacetAuthor = "author"
+	FacetPrice  = "price"
+	FacetEbook  = "ebook"
+	FacetDecade = "decade"
+)
+
+var FacetConfigs = map[string]AggregationConfig{
+	FacetAuthor: {
+		Field: "author_name.keyword",
+		Type:  AggTerms,
+		Size:  20,
+	},
+	FacetPrice: {
+		Field: "price",
+		Type:  AggRange,
+		Ranges: []AggregationRange{
+			{Key: "0-10", To: 10.0},
+			{Key: "10-25", From: 10.0, To: 25.0},
+			{Key: "25-50", From: 25.0, To: 50.0},
+			{Key: "50-100", From: 50.0, To: 100.0},
+			{Key: "100+", From: 100.0},
+		},
+	},
+	FacetEbook: {
+		Field: "ebook_available",
+		Type:  AggTerms,
+	},
+	FacetDecade: {
+		Field:  "publish_date",
+		Type:   AggDateRange,
+		Format: "yyyy",
+		Ranges: decadeRanges(1900, 2030),
+	},
+}
+
+// decadeRanges builds one "1990s" style bucket per decade in [from, to), with open ends on both sides.
+func decadeRanges(from, to int) []AggregationRange {
+	ranges := []AggregationRange{{Key: "before " + strconv.Itoa(from), To: strconv.Itoa(from)}}
+	for decade := from; decade < to; decade += 10 {
+		ranges = append(ranges, AggregationRange{
+			Key:  strconv.Itoa(decade) + "s",
+			From: strconv.Itoa(decade),
+			To:   strconv.Itoa(decade + 10),
+		})
+	}
+	return append(ranges, AggregationRange{Key: strconv.Itoa(to) + " and later", From: strconv.Itoa(to)})
+}
@@ -0,0 +1,21 @@
+package consts
+
+import "time"
+
+// Idempotency headers
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Idempotency config
+const (
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// IdempotencyLockTTL bounds how long a request holds its key before a retry may run it again,
+	// should this replica die before storing the response
+	IdempotencyLockTTL         = 1 * time.Minute
+	IdempotencyStateProcessing = "processing"
+	IdempotencyStateCompleted  = "completed"
+)
+
+var IdempotentMethods = []string{"POST", "PUT", "PATCH", "DELETE"}
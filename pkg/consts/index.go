@@ -1,21 +1,34 @@
 package consts
 
-var IndexMappings = []struct {
+// BooksIndex Logical index names, resolved to physical names by clients.IndexName
+const BooksIndex = "books"
+
+type IndexMapping struct {
 	IndexName string
 	Mapping   string
-}{
+}
+
+var IndexMappings = []IndexMapping{
 	{
-		IndexName: "books",
+		IndexName: BooksIndex,
 		Mapping: `
 		{
 		  "mappings": {
+		    "_meta": {
+		      "version": 5
+		    },
 		    "properties": {
 		      "title": {
 		        "type": "text"
 		      },
 		      "author_name": {
 		        "type": "text",
-                 "fielddata": true // cause bad performance
+		        "fields": {
+		          "keyword": {
+		            "type": "keyword",
+		            "ignore_above": 256
+		          }
+		        }
 		      },
 		      "price": {
 		        "type": "float"
@@ -27,9 +40,31 @@ var IndexMappings = []struct {
 		      "publish_date": {
 		        "type": "date",
 		        "format": "yyyy-MM-dd"
+		      },
+		      "created_at": {
+		        "type": "date"
+		      },
+		      "deleted_at": {
+		        "type": "date"
+		      },
+		      "deleted_by": {
+		        "type": "keyword"
+		      },
+		      "isbn": {
+		        "type": "keyword"
+		      },
+		      "merged_into": {
+		        "type": "keyword"
 		      }
 		    }
 		  }
 		}`,
 	},
 }
+
+// Mapping drift kinds
+const (
+	DriftMissingField    = "missing_field"
+	DriftMissingSubfield = "missing_subfield"
+	DriftTypeMismatch    = "type_mismatch"
+)
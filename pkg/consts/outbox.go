@@ -0,0 +1,47 @@
+package consts
+
+import "time"
+
+// Book change operations
+const (
+	OperationCreate   = "create"
+	OperationUpdate   = "update"
+	OperationDelete   = "delete"
+	OperationRestore  = "restore"
+	OperationUndelete = "undelete"
+)
+
+var FunctionOperations = map[Function]string{
+	DoCreateIndex:   OperationCreate,
+	DoUpdateIndex:   OperationUpdate,
+	DoDeleteIndex:   OperationDelete,
+	DoRestoreIndex:  OperationRestore,
+	DoUndeleteIndex: OperationUndelete,
+}
+
+// ActorKey holds the name of the caller whose writes end up in the outbox
+const ActorKey = "actor"
+
+// Outbox sinks
+const (
+	SinkWebhooks = "webhooks"
+	SinkFile     = "file"
+	SinkStdout   = "stdout"
+)
+
+// Outbox config
+const (
+	// OutboxMaxLen caps the stream should a sink stop consuming; entries past it are lost to that sink
+	OutboxMaxLen       = 1000000
+	OutboxBatchSize    = 100
+	OutboxPollInterval = 2 * time.Second
+	OutboxLockLease    = 30 * time.Second
+	OutboxRetryBase    = 1 * time.Second
+	OutboxRetryMax     = 1 * time.Minute
+	DefaultOutboxFile  = "outbox.ndjson"
+	// OutboxPendingGrace is how long a staged event is left to its worker before the relay
+	// checks whether its write was applied
+	OutboxPendingGrace           = 1 * time.Minute
+	DefaultOutboxRecoverInterval = 10 * time.Second
+	DefaultOutboxSpillMaxBytes   = 64 << 20
+)
@@ -0,0 +1,9 @@
+package consts
+
+// Revision history config
+const (
+	// MaxBookRevisions is how many revisions are kept per book, older ones are dropped
+	MaxBookRevisions    = 100
+	DefaultHistoryLimit = 20
+	MaxHistoryLimit     = 100
+)
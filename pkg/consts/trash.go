@@ -0,0 +1,14 @@
+package consts
+
+import "time"
+
+// Trash config
+const (
+	DeletedAtField         = "deleted_at"
+	DeletedByField         = "deleted_by"
+	DefaultTrashRetention  = 30 * 24 * time.Hour
+	DefaultTrashPurgeEvery = 1 * time.Hour
+	TrashPurgeBatchSize    = 100
+	MaxTrashPage           = 100
+	DefaultTrashPage       = 20
+)
@@ -0,0 +1,50 @@
+package consts
+
+import "time"
+
+// Book lifecycle events
+const (
+	EventBookCreated = "book.created"
+	EventBookUpdated = "book.updated"
+	EventBookDeleted = "book.deleted"
+)
+
+var BookEvents = []string{EventBookCreated, EventBookUpdated, EventBookDeleted}
+
+// FunctionEvents maps index operations to the event they emit once applied
+var FunctionEvents = map[Function]string{
+	DoCreateIndex: EventBookCreated,
+	DoUpdateIndex: EventBookUpdated,
+	DoDeleteIndex: EventBookDeleted,
+	// A restore of a deleted book is a book.created instead
+	DoRestoreIndex:  EventBookUpdated,
+	DoUndeleteIndex: EventBookCreated,
+}
+
+// Webhook delivery headers
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook delivery config
+const (
+	WebhookTimeout      = 10 * time.Second
+	WebhookMaxAttempts  = 8
+	WebhookRetryBase    = 10 * time.Second
+	WebhookRetryMax     = 1 * time.Hour
+	WebhookPollInterval = 1 * time.Second
+	WebhookBatchSize    = 20
+	WebhookWorkers      = 4
+	// WebhookDeliveryLogSize deliveries are listed per webhook, each kept for WebhookDeliveryRetention
+	WebhookDeliveryLogSize   = 100
+	WebhookDeliveryRetention = 7 * 24 * time.Hour
+)
@@ -0,0 +1,12 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetActionStats(c *gin.Context) {
+	c.JSON(http.StatusOK, clients.GetActionStats())
+}
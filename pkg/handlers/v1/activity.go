@@ -0,0 +1,174 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// exportFlushEvery is how many exported actions are written between flushes to the client
+const exportFlushEvery = 500
+
+func GetActivity(c *gin.Context) {
+	activityReq, err := utils.GetValidatedPayload[req.Activity](c)
+	if err != nil {
+		log.Errorf("Error getting activity request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, ok := activityUser(c, activityReq.User)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+		return
+	}
+
+	filter := clients.ActionFilter{
+		User:   user,
+		Method: activityReq.Method,
+		Path:   activityReq.Path,
+		BookID: activityReq.BookID,
+		From:   activityReq.From,
+		To:     activityReq.To,
+	}
+	page, err := clients.QueryActions(c, mw.GetTenant(c), filter, activityReq.Cursor, activityReq.Limit)
+	if err != nil {
+		log.Errorf("Error reading activity of %q: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func ExportActivity(c *gin.Context) {
+	exportReq, err := utils.GetValidatedPayload[req.ActivityExport](c)
+	if err != nil {
+		log.Errorf("Error getting activity export request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, ok := activityUser(c, exportReq.User)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if exportReq.Format == consts.ExportCSV {
+		contentType = "text/csv"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFileName(exportReq, user)))
+	c.Status(http.StatusOK)
+
+	writer := clients.NewActionWriter(c.Writer, exportReq.Format)
+	exported := 0
+	filter := clients.ActionFilter{User: user, From: exportReq.From, To: exportReq.To}
+	err = clients.ScanActions(c, mw.GetTenant(c), filter, func(action clients.UserAction) error {
+		if err := writer.Write(action); err != nil {
+			return err
+		}
+		exported++
+		if exported%exportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil && !c.Writer.Written() {
+		log.Errorf("Error exporting activity of %q: %v", user, err)
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		// The status is already sent, the client sees a truncated export
+		log.Errorf("Activity export of %q failed after %d actions: %v", user, exported, err)
+		_ = c.Error(err)
+		return
+	}
+	log.Infof("Exported %d actions of %q", exported, user)
+}
+
+func GetActivitySummary(c *gin.Context) {
+	summaryReq, err := utils.GetValidatedPayload[req.ActivitySummary](c)
+	if err != nil {
+		log.Errorf("Error getting activity summary request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, ok := activityUser(c, summaryReq.User)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+		return
+	}
+
+	summarizer := clients.NewActivitySummarizer(summaryReq.Top)
+	filter := clients.ActionFilter{User: user, From: summaryReq.From, To: summaryReq.To}
+	err = clients.ScanActions(c, mw.GetTenant(c), filter, func(action clients.UserAction) error {
+		summarizer.Add(action)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Error summarising activity of %q: %v", user, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summarizer.Summaries())
+}
+
+// activityUser resolves whose actions a caller reads. Callers read their own; only admins
+// name another user, or every user with "*", which resolves to an empty filter.
+func activityUser(c *gin.Context, requested string) (string, bool) {
+	user := requested
+	if user == "" {
+		user = mw.GetUserName(c)
+	}
+	if user != mw.GetUserName(c) && !mw.HasRole(c, consts.RoleAdmin) {
+		return "", false
+	}
+	if user == consts.AllUsers {
+		return "", true
+	}
+	return user, true
+}
+
+func exportFileName(exportReq req.ActivityExport, user string) string {
+	name := "activity-all"
+	if user != "" {
+		name = "activity-" + sanitizeFileName(user)
+	}
+	if !exportReq.From.IsZero() {
+		name += "-from-" + exportReq.From.UTC().Format(time.DateOnly)
+	}
+	if !exportReq.To.IsZero() {
+		name += "-to-" + exportReq.To.UTC().Format(time.DateOnly)
+	}
+	return name + "." + exportReq.Format
+}
+
+func sanitizeFileName(name string) string {
+	sanitized := []rune(name)
+	for i, r := range sanitized {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			sanitized[i] = '_'
+		}
+	}
+	return string(sanitized)
+}
@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetTimeSeries(c *gin.Context) {
+	seriesReq, err := utils.GetValidatedPayload[req.TimeSeries](c)
+	if err != nil {
+		log.Errorf("Error getting time series parameters: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	esQuery := query.NewQueryBuilder().
+		DateRange(seriesReq.Field, seriesReq.From, seriesReq.To, seriesReq.TimeZone).
+		TimeSeries(seriesReq.Field, seriesReq.Interval, seriesReq.TimeZone, seriesReq.Metrics).
+		ExcludeDeleted().
+		Build()
+
+	_, aggregations, err := clients.SearchIndex(c, esQuery, 0, 0)
+	if err != nil {
+		log.Errorf("Error fetching time series: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	series, err := utils.ParseAggregations(aggregations, map[string]consts.AggregationConfig{
+		query.TimeSeriesAggregation: query.TimeSeriesConfig(seriesReq.Field, seriesReq.Interval, seriesReq.TimeZone, seriesReq.Metrics),
+	})
+	if err != nil {
+		log.Errorf("Error parsing time series: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	buckets, _ := series[query.TimeSeriesAggregation].([]utils.Bucket)
+	c.JSON(http.StatusOK, res.TimeSeries{
+		Field:    seriesReq.Field,
+		Interval: seriesReq.Interval,
+		TimeZone: seriesReq.TimeZone,
+		Points:   res.NewTimeSeriesPoints(buckets, seriesReq.Metrics),
+	})
+}
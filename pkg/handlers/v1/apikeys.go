@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+	log "github.com/sirupsen/logrus"
+)
+
+func CreateAPIKey(c *gin.Context) {
+	createReq, err := utils.GetValidatedPayload[req.CreateAPIKey](c)
+	if err != nil {
+		log.Errorf("Error getting api key request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	roles := createReq.Roles
+	if len(roles) == 0 {
+		roles = []string{consts.RoleReader}
+	}
+
+	if createReq.Tenant != "" {
+		exists, err := clients.TenantExists(c, createReq.Tenant)
+		if err != nil {
+			log.Errorf("Error checking tenant %s: %v", createReq.Tenant, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"message": "tenant catalogue unavailable"})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Tenant not found"})
+			return
+		}
+	}
+
+	apiKey, rawKey, err := clients.CreateAPIKey(c, createReq.Owner, createReq.Tenant, roles, createReq.Scopes, createReq.ExpiresAt)
+	if err != nil {
+		log.Errorf("Error creating api key for %s: %v", createReq.Owner, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	log.Infof("API key %s created for %s", apiKey.ID, apiKey.Owner)
+	c.JSON(http.StatusCreated, res.IssuedAPIKey{APIKey: newAPIKeyResponse(apiKey), Key: rawKey})
+}
+
+func ListAPIKeys(c *gin.Context) {
+	apiKeys, err := clients.ListAPIKeys(c)
+	if err != nil {
+		log.Errorf("Error listing api keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	response := make([]res.APIKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		response = append(response, newAPIKeyResponse(apiKey))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func RotateAPIKey(c *gin.Context) {
+	idReq, err := utils.GetValidatedPayload[req.APIKeyID](c)
+	if err != nil {
+		log.Errorf("Error getting api key request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	previous, err := clients.GetAPIKey(c, idReq.ID)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	apiKey, rawKey, err := clients.RotateAPIKey(c, idReq.ID)
+	if err != nil {
+		log.Errorf("Error rotating api key %s: %v", idReq.ID, err)
+		c.JSON(apiKeyErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+	mw.ForgetAPIKey(previous.Hash)
+
+	log.Infof("API key %s rotated", apiKey.ID)
+	c.JSON(http.StatusOK, res.IssuedAPIKey{APIKey: newAPIKeyResponse(apiKey), Key: rawKey})
+}
+
+func RevokeAPIKey(c *gin.Context) {
+	idReq, err := utils.GetValidatedPayload[req.APIKeyID](c)
+	if err != nil {
+		log.Errorf("Error getting api key request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	apiKey, err := clients.RevokeAPIKey(c, idReq.ID)
+	if err != nil {
+		log.Errorf("Error revoking api key %s: %v", idReq.ID, err)
+		c.JSON(apiKeyErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+	mw.ForgetAPIKey(apiKey.Hash)
+
+	log.Infof("API key %s revoked", apiKey.ID)
+	c.JSON(http.StatusOK, newAPIKeyResponse(apiKey))
+}
+
+func newAPIKeyResponse(apiKey clients.APIKey) res.APIKey {
+	var response res.APIKey
+	_ = copier.Copy(&response, &apiKey)
+	if response.Scopes == nil {
+		response.Scopes = []string{}
+	}
+	return response
+}
+
+func apiKeyErrorStatus(err error) int {
+	if errors.Is(err, clients.ErrAPIKeyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
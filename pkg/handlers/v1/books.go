@@ -3,12 +3,17 @@ package v1
 import (
 	"book_service/pkg/clients"
 	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
 	"book_service/pkg/models/common"
 	"book_service/pkg/models/common/req"
 	"book_service/pkg/models/common/res"
 	"book_service/pkg/query"
 	"book_service/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"path"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -16,8 +21,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jinzhu/copier"
+	"github.com/samber/lo"
 )
 
+var errBookNotFound = errors.New("book not found")
+
 func GetBookById(c *gin.Context) {
 	bookReq, err := utils.GetValidatedPayload[req.GetBook](c)
 	if err != nil {
@@ -26,21 +34,28 @@ func GetBookById(c *gin.Context) {
 		return
 	}
 
-	esQuery := query.NewQueryBuilder().ID(bookReq.ID).Build()
-	hits, _, err := clients.SearchIndex(c, esQuery, 1, 0)
+	auditBook(c, bookReq.ID, nil)
+	book, hit, err := loadBook(c, bookReq.ID)
+	if errors.Is(err, errBookNotFound) {
+		if survivor := mergedInto(c, bookReq.ID); survivor != "" {
+			log.Infof("Book with ID %s was merged into %s", bookReq.ID, survivor)
+			c.Header("Location", path.Join(path.Dir(c.Request.URL.Path), survivor))
+			c.JSON(http.StatusMovedPermanently, res.MergedBook{Message: "Book was merged", MergedInto: survivor})
+			return
+		}
+		log.Infof("Book with ID %s not found", bookReq.ID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Book not found"})
+		return
+	}
 	if err != nil {
 		log.Errorf("Error searching for book with ID %s: %v", bookReq.ID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	if len(hits) == 0 {
-		log.Infof("Book with ID %s not found", bookReq.ID)
-		c.JSON(http.StatusNotFound, gin.H{"message": "Book not found"})
-		return
-	}
 
 	log.Infof("Book with ID %s retrieved successfully", bookReq.ID)
-	c.JSON(http.StatusOK, hits[0])
+	setCacheHeader(c, hit)
+	c.JSON(http.StatusOK, book)
 }
 
 func CreateBook(c *gin.Context) {
@@ -54,6 +69,7 @@ func CreateBook(c *gin.Context) {
 	book := common.Book{
 		ID:          uuid.New(),
 		PublishDate: bodyBookReq.PublishDate.Format(time.DateOnly),
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
 	}
 
 	if err := copier.Copy(&book, &bodyBookReq); err != nil {
@@ -62,9 +78,24 @@ func CreateBook(c *gin.Context) {
 		return
 	}
 
+	var duplicates []res.DuplicateMatch
+	if mode := duplicatesMode(); mode != consts.DuplicatesOff && !bodyBookReq.Force {
+		// Best effort like the other pre-write reads, the check never stops a book from being created
+		duplicates, err = findDuplicates(c, bookKey("", documentFields(book)), duplicatesThreshold())
+		if err != nil {
+			log.Warnf("Could not check book %q for duplicates: %v", book.Title, err)
+		}
+		if len(duplicates) > 0 && mode == consts.DuplicatesReject {
+			log.Infof("Book %q rejected as a duplicate of %d books", book.Title, len(duplicates))
+			c.JSON(http.StatusConflict, gin.H{"message": "Book already exists, retry with force=true to add it anyway", "duplicates": duplicates})
+			return
+		}
+	}
+
+	auditBook(c, book.ID.String(), utils.DiffFields(nil, documentFields(book)))
 	clients.EnqueueIndexTask(c, book.ID.String(), book, consts.DoCreateIndex)
 	log.Infof("Book with ID %s queued for creation successfully", book.ID)
-	c.JSON(http.StatusAccepted, res.AddBook{ID: book.ID})
+	c.JSON(http.StatusAccepted, res.AddBook{ID: book.ID, Duplicates: duplicates})
 }
 
 func UpdateBook(c *gin.Context) {
@@ -82,6 +113,15 @@ func UpdateBook(c *gin.Context) {
 		return
 	}
 
+	current, found := currentBook(c, bodyBookReq.ID)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Book not found"})
+		return
+	}
+
+	updated := documentFields(titleUpdate)
+	previous := lo.PickByKeys(current, lo.Keys(updated))
+	auditBook(c, bodyBookReq.ID, utils.DiffFields(previous, updated))
 	clients.EnqueueIndexTask(c, bodyBookReq.ID, titleUpdate, consts.DoUpdateIndex)
 	log.Infof("Book with ID %s queued for update successfully", bodyBookReq.ID)
 	c.JSON(http.StatusAccepted, res.UpdateBook{ID: uuid.MustParse(bodyBookReq.ID)})
@@ -95,7 +135,15 @@ func DeleteBook(c *gin.Context) {
 		return
 	}
 
-	clients.EnqueueIndexTask(c, deleteReq.ID, "", consts.DoDeleteIndex)
+	current, found := currentBook(c, deleteReq.ID)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Book not found"})
+		return
+	}
+
+	deletedAt, deletedBy := time.Now().UTC().Format(time.RFC3339), mw.GetUserName(c)
+	auditBook(c, deleteReq.ID, utils.DiffFields(current, nil))
+	clients.EnqueueIndexTask(c, deleteReq.ID, common.BookDeletion{DeletedAt: &deletedAt, DeletedBy: &deletedBy}, consts.DoDeleteIndex)
 	log.Infof("Book with ID %s queued for deletion successfully", deleteReq.ID)
 	c.JSON(http.StatusAccepted, res.DeleteBook{ID: uuid.MustParse(deleteReq.ID)})
 }
@@ -111,9 +159,11 @@ func SearchBooks(c *gin.Context) {
 	esQuery := query.NewQueryBuilder().
 		Title(searchReq.Title).
 		PriceRange(searchReq.PriceRange.Min, searchReq.PriceRange.Max).
+		Facets(searchReq.Facets, searchReq.FacetFilters).
+		ExcludeDeleted().
 		Build()
 
-	hits, _, err := clients.SearchIndex(c, esQuery, searchReq.Size, searchReq.From)
+	hits, aggregations, err := clients.SearchIndex(c, esQuery, searchReq.Size, searchReq.From)
 	if err != nil {
 		log.Errorf("Error searching books: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -121,28 +171,108 @@ func SearchBooks(c *gin.Context) {
 	}
 
 	log.Infof("SearchBooks esQuery executed successfully, retrieved %d results", len(hits))
-	c.JSON(http.StatusOK, hits)
+	var facets map[string][]utils.Bucket
+	if len(searchReq.Facets) > 0 {
+		facets, err = utils.ParseFacets(aggregations, searchReq.Facets)
+		if err != nil {
+			log.Errorf("Error parsing facets: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, res.SearchBooks{Hits: hits, Facets: facets})
 }
 
 func GetBooksStats(c *gin.Context) {
+	statsReq, err := utils.GetValidatedPayload[req.StoreStats](c)
+	if err != nil {
+		log.Errorf("Error getting stats filters: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	topAuthors := lo.Ternary(statsReq.Top > 0, statsReq.Top, consts.DefaultTopAuthors)
 	esQuery := query.NewQueryBuilder().
-		DistinctAuthors().
+		Title(statsReq.Title).
+		AuthorName(statsReq.AuthorName).
+		PriceRange(statsReq.MinPrice, statsReq.MaxPrice).
+		StoreStats(topAuthors).
+		ExcludeDeleted().
 		Build()
 
-	_, aggregations, err := clients.SearchIndex(c, esQuery, 0, 0, clients.EsClient.Search.WithTrackTotalHits(true))
+	cacheKey := clients.StatsCacheKey(c, clients.TenantFromContext(c), "store", statsReq)
+	storeStats, hit, err := clients.CacheGetOrLoad(c, cacheKey, clients.CacheTTL("STATS", consts.StatsCacheTTL), func() (res.StoreStats, error) {
+		result, err := clients.Search(c, esQuery, 0, 0, clients.EsClient.Search.WithTrackTotalHits(true))
+		if err != nil {
+			return res.StoreStats{}, fmt.Errorf("error fetching books statistics: %w", err)
+		}
+
+		stats, err := utils.ParseAggregations(result.Aggregations, consts.AggregationConfigs["StoreStats"])
+		if err != nil {
+			return res.StoreStats{}, fmt.Errorf("error parsing aggregations: %w", err)
+		}
+		return res.NewStoreStats(result.Total, stats), nil
+	})
 	if err != nil {
-		log.Errorf("Error fetching books statistics: %v", err)
+		log.Errorf("Error computing books statistics: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	stats, err := utils.ParseAggregations(aggregations, consts.AggregationConfigs["BookStats"])
+	log.Infof("GetBooksStats executed successfully: %+v", storeStats)
+	setCacheHeader(c, hit)
+	c.JSON(http.StatusOK, storeStats)
+}
+
+// loadBook reads a book through the cache, returning errBookNotFound when it does not exist or is in the trash.
+func loadBook(c *gin.Context, id string) (map[string]interface{}, bool, error) {
+	cacheKey := clients.BookCacheKey(clients.TenantFromContext(c), id)
+	return clients.CacheGetOrLoad(c, cacheKey, clients.CacheTTL("BOOK", consts.BookCacheTTL), func() (map[string]interface{}, error) {
+		esQuery := query.NewQueryBuilder().ID(id).ExcludeDeleted().Build()
+		hits, _, err := clients.SearchIndex(c, esQuery, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(hits) == 0 {
+			return nil, errBookNotFound
+		}
+		return hits[0], nil
+	})
+}
+
+// auditBook tells RecordActions which book a request touched and how a write changed it.
+func auditBook(c *gin.Context, id string, changes map[string]common.FieldChange) {
+	c.Set(consts.AuditBookKey, id)
+	if len(changes) > 0 {
+		c.Set(consts.AuditChangesKey, changes)
+	}
+}
+
+// currentBook is the best effort state of a book before a write, used for the audit diff.
+// It only reports the book missing when it certainly does not exist; a failed read lets the write go ahead.
+func currentBook(c *gin.Context, id string) (map[string]interface{}, bool) {
+	book, _, err := loadBook(c, id)
+	if errors.Is(err, errBookNotFound) {
+		return nil, false
+	}
 	if err != nil {
-		log.Errorf("Error parsing aggregations: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-		return
+		log.Warnf("Could not read book %s before writing it: %v", id, err)
+		return nil, true
 	}
+	return book, true
+}
+
+// documentFields turns a written document into the field map used for the audit diff.
+func documentFields(document interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	data, err := json.Marshal(document)
+	if err == nil {
+		_ = json.Unmarshal(data, &fields)
+	}
+	return fields
+}
 
-	log.Infof("GetBooksStats executed successfully: %+v", stats)
-	c.JSON(http.StatusOK, stats)
+func setCacheHeader(c *gin.Context, hit bool) {
+	c.Header(consts.CacheHeader, lo.Ternary(hit, "HIT", "MISS"))
 }
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// StreamBookChanges streams the book changes of the caller's tenant as Server-Sent Events,
+// resuming after Last-Event-ID when given and sending a comment as heartbeat while idle.
+func StreamBookChanges(c *gin.Context) {
+	changesReq, err := utils.GetValidatedPayload[req.BookChanges](c)
+	if err != nil {
+		log.Errorf("Error getting book changes request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	lastID := c.GetHeader(consts.LastEventIDHeader)
+	if lastID == "" {
+		lastID = changesReq.LastEventID
+	}
+	if lastID != "" && !utils.IsValidStreamID(lastID) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid Last-Event-ID"})
+		return
+	}
+
+	missed := false
+	if lastID == "" {
+		lastID, err = clients.LatestChangeID(c)
+	} else {
+		missed, err = clients.ChangesMissed(c, lastID)
+	}
+	if err != nil {
+		log.Errorf("Error opening book changes stream: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "change stream unavailable"})
+		return
+	}
+
+	filter := clients.ChangeFilter{
+		Tenant:     clients.TenantFromContext(c),
+		AuthorName: changesReq.AuthorName,
+		MinPrice:   changesReq.MinPrice,
+		MaxPrice:   changesReq.MaxPrice,
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", consts.ChangesRetryMs)
+	if missed {
+		// Older changes were trimmed, the client has to reload before following the stream
+		fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", consts.ChangesResetEvent)
+	}
+	c.Writer.Flush()
+
+	poll := time.NewTicker(consts.ChangesPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(consts.ChangesHeartbeat)
+	defer heartbeat.Stop()
+
+	sent := 0
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			log.Infof("Book changes stream closed by the client after %d events", sent)
+			return
+		case <-clients.ChangeStreamsClosed():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-poll.C:
+			changes, err := clients.ReadChanges(c.Request.Context(), lastID, consts.ChangesBatchSize)
+			if err != nil {
+				log.Warnf("Error reading book changes after %s: %v", lastID, err)
+				continue
+			}
+			for _, change := range changes {
+				lastID = change.ID
+				if !filter.Matches(change.Event) {
+					continue
+				}
+				if err := writeChangeEvent(c.Writer, change); err != nil {
+					return
+				}
+				sent++
+			}
+			if len(changes) > 0 {
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func writeChangeEvent(w io.Writer, change clients.BookChange) error {
+	data, err := json.Marshal(change.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", change.ID, change.Event.Type, data)
+	return err
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListDuplicateClusters reports the groups of books outside the trash that duplicate one another.
+func ListDuplicateClusters(c *gin.Context) {
+	clustersReq, err := utils.GetValidatedPayload[req.DuplicateClusters](c)
+	if err != nil {
+		log.Errorf("Error getting duplicate clusters request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	threshold := lo.FromPtrOr(clustersReq.Threshold, duplicatesThreshold())
+	var books []utils.BookKey
+	fields := []string{"title", "author_name", consts.ISBNField}
+	err = clients.ScanBooks(c, query.NewQueryBuilder().ExcludeDeleted().Build(), fields, func(id string, book map[string]interface{}) error {
+		books = append(books, bookKey(id, book))
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Error scanning books for duplicates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	clusters := utils.ClusterDuplicates(books, threshold)
+	log.Infof("Found %d duplicate clusters among %d books", len(clusters), len(books))
+	c.JSON(http.StatusOK, res.DuplicateClusters{Clusters: clusters, Scanned: len(books), Threshold: threshold})
+}
+
+// findDuplicates returns the books outside the trash that a new book would duplicate.
+func findDuplicates(c *gin.Context, book utils.BookKey, threshold float64) ([]res.DuplicateMatch, error) {
+	esQuery := query.DuplicateCandidates(book.Title, book.AuthorName, book.ISBN, threshold > 0)
+	result, err := clients.Search(c, esQuery, consts.DuplicateCandidates, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []res.DuplicateMatch
+	for i, hit := range result.Hits {
+		candidate := bookKey(result.IDs[i], hit)
+		if reason, similarity := utils.MatchDuplicate(book, candidate, threshold); reason != "" {
+			matches = append(matches, res.DuplicateMatch{BookKey: candidate, Reason: reason, Similarity: similarity})
+		}
+	}
+	return matches, nil
+}
+
+func bookKey(id string, book map[string]interface{}) utils.BookKey {
+	title, _ := book["title"].(string)
+	author, _ := book["author_name"].(string)
+	isbn, _ := book[consts.ISBNField].(string)
+	return utils.BookKey{ID: id, Title: title, AuthorName: author, ISBN: isbn}
+}
+
+// duplicatesMode is what creating a book that duplicates existing ones does: reject, warn or off.
+func duplicatesMode() string {
+	// Warning by default keeps existing clients working, rejecting is opted into
+	mode, _ := utils.GetEnvVar[string]("DUPLICATES_MODE", consts.DuplicatesWarn)
+	if !lo.Contains([]string{consts.DuplicatesReject, consts.DuplicatesWarn, consts.DuplicatesOff}, mode) {
+		log.Warnf("Unknown DUPLICATES_MODE %q, only warning about duplicates", mode)
+		return consts.DuplicatesWarn
+	}
+	return mode
+}
+
+// duplicatesThreshold is the title similarity from which books by the same author are duplicates, 0 disabling it.
+func duplicatesThreshold() float64 {
+	threshold, _ := utils.GetEnvVar[float64]("DUPLICATES_FUZZY_THRESHOLD", 0)
+	return threshold
+}
@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/utils"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+func GetBookHistory(c *gin.Context) {
+	historyReq, err := utils.GetValidatedPayload[req.BookHistory](c)
+	if err != nil {
+		log.Errorf("Error getting book history request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	limit := lo.Ternary(historyReq.Limit > 0, historyReq.Limit, consts.DefaultHistoryLimit)
+	page, err := clients.ListRevisions(c, clients.TenantFromContext(c), historyReq.ID, historyReq.Before, limit)
+	if err != nil {
+		log.Errorf("Error listing revisions of book %s: %v", historyReq.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func DiffBookRevisions(c *gin.Context) {
+	diffReq, err := utils.GetValidatedPayload[req.BookRevisionDiff](c)
+	if err != nil {
+		log.Errorf("Error getting revision diff request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	tenant := clients.TenantFromContext(c)
+	from, err := clients.GetRevision(c, tenant, diffReq.ID, diffReq.From)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"message": fmt.Sprintf("revision %d: %v", diffReq.From, err)})
+		return
+	}
+	to, err := clients.GetRevision(c, tenant, diffReq.ID, diffReq.To)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"message": fmt.Sprintf("revision %d: %v", diffReq.To, err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, res.RevisionDiff{
+		ID:      diffReq.ID,
+		From:    from.Revision,
+		To:      to.Revision,
+		Changes: utils.DiffFields(from.Snapshot, to.Snapshot),
+	})
+}
+
+// RestoreBook queues a write putting a book back the way a revision left it, recreating it if deleted.
+func RestoreBook(c *gin.Context) {
+	restoreReq, err := utils.GetValidatedPayload[req.RestoreBook](c)
+	if err != nil {
+		log.Errorf("Error getting restore request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	revision, err := clients.GetRevision(c, clients.TenantFromContext(c), restoreReq.ID, restoreReq.Revision)
+	if err != nil {
+		log.Errorf("Error reading revision %d of book %s: %v", restoreReq.Revision, restoreReq.ID, err)
+		c.JSON(revisionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+	if revision.Snapshot == nil {
+		c.JSON(http.StatusConflict, gin.H{"message": fmt.Sprintf("revision %d deleted the book, restore an earlier one", revision.Revision)})
+		return
+	}
+
+	current, _ := currentBook(c, restoreReq.ID)
+	auditBook(c, restoreReq.ID, utils.DiffFields(current, revision.Snapshot))
+	clients.EnqueueIndexTask(c, restoreReq.ID, revision.Snapshot, consts.DoRestoreIndex)
+	log.Infof("Book with ID %s queued for restore to revision %d", restoreReq.ID, revision.Revision)
+	c.JSON(http.StatusAccepted, res.RestoreBook{ID: uuid.MustParse(restoreReq.ID), Revision: revision.Revision})
+}
+
+func revisionErrorStatus(err error) int {
+	if errors.Is(err, clients.ErrRevisionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListIndices(c *gin.Context) {
+	indices, err := clients.ListIndices(c)
+	if err != nil {
+		log.Errorf("Error listing indices: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, indices)
+}
+
+func GetIndex(c *gin.Context) {
+	indexReq, err := utils.GetValidatedPayload[req.IndexName](c)
+	if err != nil {
+		log.Errorf("Error getting index: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	index, err := clients.GetIndex(c, indexReq.Index)
+	if err != nil {
+		log.Errorf("Error getting index %s: %v", indexReq.Index, err)
+		c.JSON(indexErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, index)
+}
+
+func ReindexIndex(c *gin.Context) {
+	reindexReq, err := utils.GetValidatedPayload[req.Reindex](c)
+	if err != nil {
+		log.Errorf("Error getting reindex payload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	taskID, err := clients.StartReindex(c, reindexReq.Index, reindexReq.Dest)
+	if err != nil {
+		log.Errorf("Error starting reindex of %s into %s: %v", reindexReq.Index, reindexReq.Dest, err)
+		c.JSON(indexErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, res.Reindex{TaskID: taskID, Source: reindexReq.Index, Dest: reindexReq.Dest})
+}
+
+func GetReindexTask(c *gin.Context) {
+	taskReq, err := utils.GetValidatedPayload[req.ReindexTask](c)
+	if err != nil {
+		log.Errorf("Error getting task payload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	task, err := clients.GetReindexTask(c, taskReq.TaskID)
+	if err != nil {
+		log.Errorf("Error getting task %s: %v", taskReq.TaskID, err)
+		if errors.Is(err, clients.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Task " + taskReq.TaskID + " not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+func RefreshIndex(c *gin.Context) {
+	applyIndexAction(c, "refresh", clients.RefreshIndex)
+}
+
+func FlushIndex(c *gin.Context) {
+	applyIndexAction(c, "flush", clients.FlushIndex)
+}
+
+func CloseIndex(c *gin.Context) {
+	applyIndexAction(c, "close", clients.CloseIndex)
+}
+
+func OpenIndex(c *gin.Context) {
+	applyIndexAction(c, "open", clients.OpenIndex)
+}
+
+func applyIndexAction(c *gin.Context, action string, apply func(context.Context, string) error) {
+	indexReq, err := utils.GetValidatedPayload[req.IndexName](c)
+	if err != nil {
+		log.Errorf("Error getting index for %s: %v", action, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := apply(c, indexReq.Index); err != nil {
+		log.Errorf("Error applying %s on index %s: %v", action, indexReq.Index, err)
+		c.JSON(indexErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	log.Infof("Index %s: %s applied successfully", indexReq.Index, action)
+	c.JSON(http.StatusOK, res.IndexAction{Index: indexReq.Index, Action: action})
+}
+
+func indexErrorStatus(err error) int {
+	if errors.Is(err, clients.ErrIndexNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+// MergeBooks folds duplicate books into a survivor: the survivor is updated with the chosen values and
+// the duplicates are moved to the trash, marked as merged into it so that reads of them redirect.
+// Both happen in one index task, which leaves the duplicates alone when the survivor cannot be written.
+func MergeBooks(c *gin.Context) {
+	mergeReq, err := utils.GetValidatedPayload[req.MergeBooks](c)
+	if err != nil {
+		log.Errorf("Error getting merge request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	books := make(map[string]map[string]interface{}, len(mergeReq.DuplicateIDs)+1)
+	for _, id := range append([]string{mergeReq.SurvivorID}, mergeReq.DuplicateIDs...) {
+		// Read past the cache, the merge is computed from these values
+		book, err := clients.GetBook(c, id)
+		if err != nil {
+			log.Errorf("Error reading book %s to merge: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		if book == nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Book " + id + " not found"})
+			return
+		}
+		books[id] = book
+	}
+
+	if err := mergeReq.ValidateChoices(books); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	survivor := books[mergeReq.SurvivorID]
+	merged := utils.MergeFields(books, mergeReq.SurvivorID, mergeReq.DuplicateIDs, mergeReq.Fields)
+	changes := utils.DiffFields(lo.PickByKeys(survivor, consts.MergeFields), merged)
+	deletedAt, deletedBy := time.Now().UTC().Format(time.RFC3339), mw.GetUserName(c)
+	clients.EnqueueIndexTask(c, mergeReq.SurvivorID, common.BookMerge{
+		Survivor:     lo.PickByKeys(merged, lo.Keys(changes)),
+		DuplicateIDs: mergeReq.DuplicateIDs,
+		Deletion:     common.BookDeletion{DeletedAt: &deletedAt, DeletedBy: &deletedBy, MergedInto: &mergeReq.SurvivorID},
+	}, consts.DoMergeIndex)
+
+	auditChanges := lo.Assign(changes, map[string]common.FieldChange{
+		consts.MergedFromChange: {To: mergeReq.DuplicateIDs},
+	})
+	auditBook(c, mergeReq.SurvivorID, auditChanges)
+	log.Infof("Books %v queued for merging into %s", mergeReq.DuplicateIDs, mergeReq.SurvivorID)
+	c.JSON(http.StatusAccepted, res.MergeBooks{
+		ID:      uuid.MustParse(mergeReq.SurvivorID),
+		Merged:  mergeReq.DuplicateIDs,
+		Changes: changes,
+	})
+}
+
+// mergedInto is the book a deleted book was merged into, empty when it was not merged or cannot be read.
+func mergedInto(c *gin.Context, id string) string {
+	survivor, err := clients.MergedInto(c, id)
+	if err != nil {
+		log.Warnf("Could not look up whether book %s was merged: %v", id, err)
+	}
+	return survivor
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func GetOutboxStatus(c *gin.Context) {
+	status, err := clients.GetOutboxStatus(c)
+	if err != nil {
+		log.Errorf("Error reading outbox status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func RemoveOutboxSink(c *gin.Context) {
+	sinkReq, err := utils.GetValidatedPayload[req.OutboxSink](c)
+	if err != nil {
+		log.Errorf("Error getting outbox sink request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := clients.RemoveOutboxSink(c, sinkReq.Name); err != nil {
+		log.Errorf("Error removing outbox sink %s: %v", sinkReq.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	log.Infof("Outbox sink %s removed", sinkReq.Name)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateTenant provisions the indices of a tenant, after which requests may select it. Provisioning twice is harmless.
+func CreateTenant(c *gin.Context) {
+	tenantReq, err := utils.GetValidatedPayload[req.CreateTenant](c)
+	if err != nil {
+		log.Errorf("Error getting tenant request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	indices, err := clients.ProvisionTenant(tenantReq.ID)
+	if err != nil {
+		log.Errorf("Error provisioning tenant %s: %v", tenantReq.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	log.Infof("Tenant %s provisioned with indices %v", tenantReq.ID, indices)
+	c.JSON(http.StatusCreated, res.Tenant{ID: tenantReq.ID, Indices: indices})
+}
@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+func ListTrash(c *gin.Context) {
+	trashReq, err := utils.GetValidatedPayload[req.Trash](c)
+	if err != nil {
+		log.Errorf("Error getting trash request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	size := lo.Ternary(trashReq.Size > 0, trashReq.Size, consts.DefaultTrashPage)
+	esQuery := query.NewQueryBuilder().OnlyDeleted().Build()
+	result, err := clients.Search(c, esQuery, size, trashReq.From,
+		clients.EsClient.Search.WithSort(consts.DeletedAtField+":desc"),
+		clients.EsClient.Search.WithTrackTotalHits(true),
+	)
+	if err != nil {
+		log.Errorf("Error listing the trash: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	trash := res.Trash{Books: make([]res.TrashedBook, 0, len(result.Hits)), Total: result.Total}
+	for i, book := range result.Hits {
+		trash.Books = append(trash.Books, newTrashedBookResponse(result.IDs[i], book))
+	}
+	c.JSON(http.StatusOK, trash)
+}
+
+// UndeleteBook takes a book out of the trash.
+func UndeleteBook(c *gin.Context) {
+	trashReq, ok := trashedBook(c)
+	if !ok {
+		return
+	}
+
+	auditBook(c, trashReq.ID, nil)
+	clients.EnqueueIndexTask(c, trashReq.ID, common.BookDeletion{}, consts.DoUndeleteIndex)
+	log.Infof("Book with ID %s queued for restore from the trash", trashReq.ID)
+	c.JSON(http.StatusAccepted, res.UpdateBook{ID: uuid.MustParse(trashReq.ID)})
+}
+
+// PurgeBook removes a book in the trash for good, along with its revisions.
+func PurgeBook(c *gin.Context) {
+	trashReq, ok := trashedBook(c)
+	if !ok {
+		return
+	}
+
+	auditBook(c, trashReq.ID, nil)
+	clients.EnqueueIndexTask(c, trashReq.ID, nil, consts.DoPurgeIndex)
+	log.Infof("Book with ID %s queued for purging", trashReq.ID)
+	c.JSON(http.StatusAccepted, res.DeleteBook{ID: uuid.MustParse(trashReq.ID)})
+}
+
+// trashedBook validates a request on a book in the trash, responding when it is not there.
+func trashedBook(c *gin.Context) (req.TrashedBook, bool) {
+	trashReq, err := utils.GetValidatedPayload[req.TrashedBook](c)
+	if err != nil {
+		log.Errorf("Error getting trash request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return trashReq, false
+	}
+
+	if _, err := clients.GetTrashedBook(c, trashReq.ID); err != nil {
+		if errors.Is(err, clients.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return trashReq, false
+		}
+		log.Errorf("Error reading book %s from the trash: %v", trashReq.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return trashReq, false
+	}
+	return trashReq, true
+}
+
+func newTrashedBookResponse(id string, book map[string]interface{}) res.TrashedBook {
+	deletedAt, _ := book[consts.DeletedAtField].(string)
+	deletedBy, _ := book[consts.DeletedByField].(string)
+	trashed := res.TrashedBook{
+		ID:        id,
+		DeletedAt: deletedAt,
+		DeletedBy: deletedBy,
+		Book:      lo.OmitByKeys(book, []string{consts.DeletedAtField, consts.DeletedByField}),
+	}
+	if at, err := time.Parse(time.RFC3339, deletedAt); err == nil {
+		trashed.PurgeAt = at.Add(clients.TrashRetention()).UTC().Format(time.RFC3339)
+	}
+	return trashed
+}
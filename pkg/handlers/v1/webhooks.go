@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+	log "github.com/sirupsen/logrus"
+)
+
+func CreateWebhook(c *gin.Context) {
+	createReq, err := utils.GetValidatedPayload[req.CreateWebhook](c)
+	if err != nil {
+		log.Errorf("Error getting webhook request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if createReq.Tenant != "" && !utils.IsValidTenant(createReq.Tenant) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid tenant"})
+		return
+	}
+
+	webhook, err := clients.CreateWebhook(c, clients.Webhook{
+		URL:    createReq.URL,
+		Events: createReq.Events,
+		Tenant: createReq.Tenant,
+		Secret: createReq.Secret,
+	})
+	if err != nil {
+		log.Errorf("Error creating webhook for %s: %v", createReq.URL, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	log.Infof("Webhook %s created for %s", webhook.ID, webhook.URL)
+	c.JSON(http.StatusCreated, res.CreatedWebhook{Webhook: newWebhookResponse(webhook), Secret: webhook.Secret})
+}
+
+func ListWebhooks(c *gin.Context) {
+	webhooks, err := clients.ListWebhooks(c)
+	if err != nil {
+		log.Errorf("Error listing webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	response := make([]res.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, newWebhookResponse(webhook))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func GetWebhook(c *gin.Context) {
+	idReq, err := utils.GetValidatedPayload[req.WebhookID](c)
+	if err != nil {
+		log.Errorf("Error getting webhook request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	webhook, err := clients.GetWebhook(c, idReq.ID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(webhook))
+}
+
+func DeleteWebhook(c *gin.Context) {
+	idReq, err := utils.GetValidatedPayload[req.WebhookID](c)
+	if err != nil {
+		log.Errorf("Error getting webhook request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	webhook, err := clients.DeleteWebhook(c, idReq.ID)
+	if err != nil {
+		log.Errorf("Error deleting webhook %s: %v", idReq.ID, err)
+		c.JSON(webhookErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	log.Infof("Webhook %s deleted", webhook.ID)
+	c.JSON(http.StatusOK, newWebhookResponse(webhook))
+}
+
+func ListWebhookDeliveries(c *gin.Context) {
+	idReq, err := utils.GetValidatedPayload[req.WebhookID](c)
+	if err != nil {
+		log.Errorf("Error getting webhook request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	deliveries, err := clients.ListWebhookDeliveries(c, idReq.ID)
+	if err != nil {
+		log.Errorf("Error listing deliveries of webhook %s: %v", idReq.ID, err)
+		c.JSON(webhookErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func RedeliverWebhook(c *gin.Context) {
+	deliveryReq, err := utils.GetValidatedPayload[req.WebhookDelivery](c)
+	if err != nil {
+		log.Errorf("Error getting webhook delivery request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	delivery, err := clients.RedeliverWebhook(c, deliveryReq.ID, deliveryReq.DeliveryID)
+	if err != nil {
+		log.Errorf("Error redelivering %s of webhook %s: %v", deliveryReq.DeliveryID, deliveryReq.ID, err)
+		c.JSON(webhookErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	log.Infof("Delivery %s of webhook %s scheduled again as %s", deliveryReq.DeliveryID, deliveryReq.ID, delivery.ID)
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func newWebhookResponse(webhook clients.Webhook) res.Webhook {
+	var response res.Webhook
+	_ = copier.Copy(&response, &webhook)
+	if response.Events == nil {
+		response.Events = []string{}
+	}
+	return response
+}
+
+func webhookErrorStatus(err error) int {
+	if errors.Is(err, clients.ErrWebhookNotFound) || errors.Is(err, clients.ErrDeliveryNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
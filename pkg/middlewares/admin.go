@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth lets through requests carrying the configured ADMIN_TOKEN and, when authentication
+// is enabled, principals with the admin role. Other authenticated principals get 403.
+// When neither is configured every admin request is rejected.
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminToken, _ := utils.GetEnvVar[string]("ADMIN_TOKEN", "")
+		token := c.GetHeader(consts.AdminTokenHeader)
+
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			c.Next()
+			return
+		}
+
+		if authEnabled() {
+			if principal, ok := GetPrincipal(c); ok {
+				if !principal.HasRole(consts.RoleAdmin) || !principal.HasScope(consts.APIKeyScopeAdmin) {
+					forbidden(c)
+					return
+				}
+				c.Next()
+				return
+			}
+		}
+
+		unauthorized(c)
+	}
+}
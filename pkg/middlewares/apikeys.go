@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// Unknown keys are cached as nil, so a client retrying a bad key does not hit Redis every time
+	apiKeyCache   = utils.NewTTLCache[*clients.APIKey](consts.APIKeyCacheTTL, consts.APIKeyCacheSize)
+	apiKeyTouches sync.Map
+)
+
+// resolveAPIKey finds a managed API key by its hash, through the in-process cache.
+func resolveAPIKey(ctx context.Context, hash string) (*clients.APIKey, error) {
+	now := time.Now()
+	if apiKey, ok := apiKeyCache.Get(hash, now); ok {
+		if apiKey == nil || apiKey.Expired(now) {
+			return nil, nil
+		}
+		return apiKey, nil
+	}
+
+	apiKey, err := clients.LookupAPIKey(ctx, hash)
+	if errors.Is(err, clients.ErrAPIKeyNotFound) {
+		apiKeyCache.Set(hash, nil, now)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	apiKeyCache.Set(hash, &apiKey, now)
+	return &apiKey, nil
+}
+
+// touchAPIKey records the use of a key at most once per consts.APIKeyTouchInterval per process.
+func touchAPIKey(apiKey *clients.APIKey) {
+	id := apiKey.ID
+	now := time.Now()
+	if last, ok := apiKeyTouches.Load(id); ok && now.Sub(last.(time.Time)) < consts.APIKeyTouchInterval {
+		return
+	}
+	apiKeyTouches.Store(id, now)
+
+	go func() {
+		if err := clients.TouchAPIKey(context.Background(), id, apiKey.Hash, now); err != nil {
+			log.Warnf("Failed to record use of api key %s: %v", id, err)
+		}
+	}()
+}
+
+// ForgetAPIKey drops a key from the in-process cache once it has been rotated or revoked.
+func ForgetAPIKey(hash string) {
+	apiKeyCache.Delete(hash)
+}
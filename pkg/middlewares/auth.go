@@ -0,0 +1,246 @@
+package middlewares
+
+import (
+	"book_service/pkg/consts"
+	m "book_service/pkg/models/common"
+	"book_service/pkg/utils"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type authSettings struct {
+	signature  string
+	verifier   *utils.JWTVerifier
+	staticKeys map[string]utils.StaticAPIKey
+}
+
+var (
+	authMutex  sync.Mutex
+	cachedAuth *authSettings
+)
+
+// Authenticate resolves the caller from an "Authorization: Bearer <jwt>" or X-API-Key header
+// when AUTH_ENABLED is set. Invalid credentials are rejected, missing ones leave the request anonymous
+// for RequireAuth to decide. The resolved caller is kept under consts.ActorKey for the outbox.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authEnabled() {
+			c.Set(consts.ActorKey, GetUserName(c))
+			c.Next()
+			return
+		}
+
+		settings, err := loadAuthSettings()
+		if err != nil {
+			log.Errorf("Authentication is misconfigured: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "authentication unavailable"})
+			c.Abort()
+			return
+		}
+
+		principal, err := settings.authenticate(c)
+		if err != nil {
+			log.Infof("Rejected credentials from %s: %v", c.ClientIP(), err)
+			unauthorized(c)
+			return
+		}
+
+		if principal != nil {
+			c.Set(consts.PrincipalKey, *principal)
+			c.Set(consts.TierKey, principal.Tier)
+		}
+		c.Set(consts.ActorKey, GetUserName(c))
+		c.Next()
+	}
+}
+
+// RequireAuth rejects anonymous callers of a route group, unless AUTH_ANONYMOUS_<GROUP>
+// or consts.AnonymousAccess allows them, and API keys not scoped to the group.
+func RequireAuth(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authEnabled() {
+			c.Next()
+			return
+		}
+		if principal, ok := GetPrincipal(c); ok {
+			if !principal.HasScope(group) {
+				forbidden(c)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		allowed, _ := utils.GetEnvVar[bool]("AUTH_ANONYMOUS_"+strings.ToUpper(group), consts.AnonymousAccess[group])
+		if !allowed {
+			unauthorized(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole only lets through principals holding role or a higher one.
+// Anonymous callers get 401, authenticated callers lacking the role get 403.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authEnabled() {
+			c.Next()
+			return
+		}
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			unauthorized(c)
+			return
+		}
+		if !principal.HasRole(role) {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasRole reports whether the caller holds role. Every caller does while authentication is disabled.
+func HasRole(c *gin.Context, role string) bool {
+	if !authEnabled() {
+		return true
+	}
+	principal, ok := GetPrincipal(c)
+	return ok && principal.HasRole(role)
+}
+
+func GetPrincipal(c *gin.Context) (m.Principal, bool) {
+	value, exists := c.Get(consts.PrincipalKey)
+	if !exists {
+		return m.Principal{}, false
+	}
+	principal, ok := value.(m.Principal)
+	return principal, ok
+}
+
+func authEnabled() bool {
+	enabled, _ := utils.GetEnvVar[bool]("AUTH_ENABLED", false)
+	return enabled
+}
+
+func unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Bearer realm="book_service"`)
+	c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+	c.Abort()
+}
+
+func forbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+	c.Abort()
+}
+
+func (s *authSettings) authenticate(c *gin.Context) (*m.Principal, error) {
+	if apiKey := c.GetHeader(consts.APIKeyHeader); apiKey != "" {
+		hash := utils.HashAPIKey(apiKey)
+		if staticKey, ok := s.staticKeys[hash]; ok {
+			return &m.Principal{
+				Subject: staticKey.Subject,
+				Roles:   staticKey.Roles,
+				Tier:    consts.TierUser,
+				Method:  m.AuthAPIKey,
+				Tenant:  staticKey.Tenant,
+			}, nil
+		}
+
+		managedKey, err := resolveAPIKey(c, hash)
+		if err != nil {
+			// Without the key store managed keys cannot be told apart from unknown ones
+			log.Warnf("API key lookup failed: %v", err)
+		}
+		if managedKey == nil {
+			return nil, fmt.Errorf("unknown api key")
+		}
+		touchAPIKey(managedKey)
+		return &m.Principal{
+			Subject: managedKey.Owner,
+			Roles:   managedKey.Roles,
+			Tier:    consts.TierUser,
+			Method:  m.AuthAPIKey,
+			Scopes:  managedKey.Scopes,
+			Tenant:  managedKey.Tenant,
+		}, nil
+	}
+
+	authorization := c.GetHeader("Authorization")
+	if authorization == "" {
+		return nil, nil
+	}
+	token, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found {
+		return nil, fmt.Errorf("unsupported authorization scheme")
+	}
+
+	claims, err := s.verifier.Verify(strings.TrimSpace(token), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	tier := claims.Tier
+	if tier == "" {
+		tier = consts.TierUser
+	}
+	// The claim ends up in index names and Redis keys, like the tenants of API keys and the header
+	tenant := strings.ToLower(strings.TrimSpace(claims.Tenant))
+	if tenant != "" && !utils.IsValidTenant(tenant) {
+		return nil, fmt.Errorf("invalid tenant claim %q", claims.Tenant)
+	}
+	return &m.Principal{
+		Subject: claims.Subject,
+		Roles:   claims.Roles,
+		Tier:    tier,
+		Method:  m.AuthJWT,
+		Tenant:  tenant,
+	}, nil
+}
+
+// loadAuthSettings builds the verifier and key set once per configuration, reading the JWKS file
+// again only when the configuration changes.
+func loadAuthSettings() (*authSettings, error) {
+	secret, _ := utils.GetEnvVar[string]("JWT_HS256_SECRET", "")
+	jwksFile, _ := utils.GetEnvVar[string]("JWT_JWKS_FILE", "")
+	issuer, _ := utils.GetEnvVar[string]("JWT_ISSUER", "")
+	audience, _ := utils.GetEnvVar[string]("JWT_AUDIENCE", "")
+	apiKeys, _ := utils.GetEnvVar[string]("API_KEYS", "")
+	signature := strings.Join([]string{secret, jwksFile, issuer, audience, apiKeys}, "\x00")
+
+	authMutex.Lock()
+	defer authMutex.Unlock()
+
+	if cachedAuth != nil && cachedAuth.signature == signature {
+		return cachedAuth, nil
+	}
+
+	var jwks []byte
+	if jwksFile != "" {
+		data, err := os.ReadFile(jwksFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading jwks file: %w", err)
+		}
+		jwks = data
+	}
+
+	verifier, err := utils.NewJWTVerifier([]byte(secret), jwks, issuer, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	staticKeys, err := utils.ParseStaticAPIKeys(apiKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedAuth = &authSettings{signature: signature, verifier: verifier, staticKeys: staticKeys}
+	return cachedAuth, nil
+}
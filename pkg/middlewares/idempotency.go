@@ -0,0 +1,110 @@
+package middlewares
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+// Idempotency makes writes sent with an Idempotency-Key header safe to retry. The first request
+// with a key runs and its response is kept for IDEMPOTENCY_TTL; repeats get that response replayed,
+// reusing the key for a different request is rejected with 422. Server errors are not kept, so a retry
+// runs again. Without Redis requests go through unprotected.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(consts.IdempotencyKeyHeader)
+		if key == "" || !lo.Contains(consts.IdempotentMethods, c.Request.Method) {
+			c.Next()
+			return
+		}
+		if !utils.IsValidIdempotencyKey(key) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid " + consts.IdempotencyKeyHeader})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "unreadable request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := clients.IdempotencyKey(GetTenant(c), GetUserName(c), key)
+		fingerprint := utils.RequestFingerprint(c.Request.Method, c.Request.URL.RequestURI(), body)
+		stored, err := clients.BeginIdempotentRequest(c, storeKey, fingerprint)
+		switch {
+		case errors.Is(err, clients.ErrIdempotencyMismatch):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			c.Abort()
+			return
+		case errors.Is(err, clients.ErrIdempotencyInProgress):
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			c.Abort()
+			return
+		case err != nil:
+			log.Warnf("Idempotency unavailable, letting request through: %v", err)
+			c.Next()
+			return
+		case stored != nil:
+			c.Header(consts.IdempotentReplayedHeader, "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// The request context may already be cancelled, the outcome has to be stored regardless
+		ctx := context.Background()
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := clients.ReleaseIdempotentRequest(ctx, storeKey); err != nil {
+				log.Warnf("Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		ttl, _ := utils.GetEnvVar[time.Duration]("IDEMPOTENCY_TTL", consts.DefaultIdempotencyTTL)
+		err = clients.CompleteIdempotentRequest(ctx, storeKey, clients.IdempotentRequest{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+			CreatedAt:   time.Now().UTC(),
+		}, ttl)
+		if err != nil {
+			log.Warnf("Failed to store the response of idempotency key: %v", err)
+		}
+	}
+}
+
+// responseRecorder keeps a copy of the response body while writing it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -14,6 +14,7 @@ func Logger() gin.HandlerFunc {
 		c.Next()
 
 		log.WithFields(log.Fields{
+			"request_id": GetRequestID(c),
 			"status":     c.Writer.Status(),
 			"method":     c.Request.Method,
 			"path":       c.Request.URL.Path,
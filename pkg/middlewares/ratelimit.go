@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// RateLimit limits every user of a route group according to their tier.
+// RATE_LIMIT_<GROUP>_<TIER>, e.g. RATE_LIMIT_SEARCH_USER=200/1m, overrides consts.RateLimits.
+// Counters live in Redis so the limit holds across replicas; if Redis fails the request goes through.
+func RateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if enabled, _ := utils.GetEnvVar[bool]("RATE_LIMIT_ENABLED", true); !enabled {
+			c.Next()
+			return
+		}
+
+		tier := GetUserTier(c)
+		limit, window, err := rateLimitFor(group, tier)
+		if err != nil {
+			log.Errorf("Invalid rate limit for %s/%s: %v", group, tier, err)
+			c.Next()
+			return
+		}
+
+		key := "ratelimit:" + group + ":" + GetTenant(c) + ":" + GetUserName(c)
+		result, err := clients.AllowRequest(c, key, limit, window)
+		if err != nil {
+			log.Warnf("Rate limiting unavailable, letting request through: %v", err)
+			c.Next()
+			return
+		}
+
+		resetSeconds := strconv.Itoa(int(math.Ceil(result.ResetIn.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", resetSeconds)
+
+		if !result.Allowed {
+			c.Header("Retry-After", resetSeconds)
+			c.JSON(http.StatusTooManyRequests, gin.H{"message": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetUserTier returns the tier set by authentication, otherwise callers naming themselves
+// are users and the rest anonymous.
+func GetUserTier(c *gin.Context) string {
+	if tier := c.GetString(consts.TierKey); tier != "" {
+		return tier
+	}
+	if !authEnabled() && c.GetHeader("X-Username") != "" {
+		return consts.TierUser
+	}
+	return consts.TierAnonymous
+}
+
+func rateLimitFor(group, tier string) (int, time.Duration, error) {
+	fallback := consts.RateLimits[group][tier]
+	if fallback == "" {
+		fallback = consts.RateLimits[group][consts.TierAnonymous]
+	}
+	limit, _ := utils.GetEnvVar[string]("RATE_LIMIT_"+strings.ToUpper(group)+"_"+strings.ToUpper(tier), fallback)
+	return utils.ParseRateLimit(limit)
+}
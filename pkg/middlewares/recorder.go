@@ -3,35 +3,73 @@ package middlewares
 import (
 	"book_service/pkg/clients"
 	"book_service/pkg/consts"
+	m "book_service/pkg/models/common"
+	"book_service/pkg/utils"
+	"math/rand"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/samber/lo"
 )
 
+// RecordActions appends handled requests to the audit trail with their outcome and, for writes,
+// the book and fields they changed. Which requests are kept is decided by the route's Audit group.
 func RecordActions() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if isActionRoute(c.Request.URL.Path) {
-			c.Next()
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		sampleRate := auditSampleRate(c.GetString(consts.AuditGroupKey))
+		if !shouldRecord(status, sampleRate, rand.Float64()) {
 			return
 		}
+
 		action := clients.UserAction{
-			Method: c.Request.Method,
-			Path:   c.Request.URL.Path,
-			Time:   time.Now(),
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			Route:         c.FullPath(),
+			Time:          start,
+			User:          GetUserName(c),
+			Tenant:        GetTenant(c),
+			Status:        status,
+			ErrorCategory: utils.ErrorCategory(status),
+			LatencyMs:     time.Since(start).Milliseconds(),
+			RequestID:     GetRequestID(c),
+			BookID:        c.GetString(consts.AuditBookKey),
+		}
+		if sampleRate < 1 {
+			action.SampleRate = sampleRate
+		}
+		if changes, ok := c.Get(consts.AuditChangesKey); ok {
+			action.Changes, _ = changes.(map[string]m.FieldChange)
 		}
-		username := GetUserName(c)
-		action.User = username
 
 		clients.AppendAction(action)
+	}
+}
 
+// Audit puts the routes it guards in an audit group, whose sample rate decides which of their
+// requests RecordActions keeps. A rate of 0 opts the routes out.
+func Audit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(consts.AuditGroupKey, group)
 		c.Next()
 	}
 }
 
+// GetUserName identifies the caller by its authenticated subject. Without authentication
+// the X-Username header is trusted as before, falling back to the client IP.
 func GetUserName(c *gin.Context) string {
-	username := c.GetHeader("X-Username")
+	if principal, ok := GetPrincipal(c); ok {
+		return principal.Subject
+	}
+
+	username := ""
+	if !authEnabled() {
+		username = c.GetHeader("X-Username")
+	}
 
 	if username == "" {
 		username = c.ClientIP()
@@ -39,8 +77,25 @@ func GetUserName(c *gin.Context) string {
 	return username
 }
 
-func isActionRoute(path string) bool {
-	segments := strings.Split(path, "/")
-	present := lo.Contains(segments, consts.ActionRoute)
-	return present
+func auditSampleRate(group string) float64 {
+	if group == "" {
+		return 1
+	}
+	fallback, ok := consts.AuditSampleRates[group]
+	if !ok {
+		fallback = 1
+	}
+	rate, _ := utils.GetEnvVar[float64]("AUDIT_SAMPLE_"+strings.ToUpper(group), fallback)
+	return rate
+}
+
+// shouldRecord keeps every failure of a group that is not opted out, and a sample of its successes.
+func shouldRecord(status int, sampleRate, draw float64) bool {
+	if sampleRate <= 0 {
+		return false
+	}
+	if status >= 400 || sampleRate >= 1 {
+		return true
+	}
+	return draw < sampleRate
 }
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"book_service/pkg/consts"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID keeps the caller's X-Request-ID when it is well formed, otherwise generates one,
+// and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(consts.RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(consts.RequestIDKey, requestID)
+		c.Header(consts.RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(consts.RequestIDKey)
+}
@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+var tenantExemptPrefixes = []string{"/ping", "/" + consts.AdminRoute}
+
+// Tenant scopes the request to the caller's tenant. Principals bound to a tenant, by the tenant claim
+// of their JWT or the tenant of their API key, are scoped to it and get 403 when TENANT_HEADER
+// (X-Tenant-ID by default) names another one. Other callers may only pick a tenant with the header
+// while authentication is disabled, or as admins. Tenants are provisioned through /admin/tenants,
+// unknown ones get 404. Requests without a tenant use the shared catalogue unless TENANT_REQUIRED is set.
+// Runs after Authenticate.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isTenantExempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		header, _ := utils.GetEnvVar[string]("TENANT_HEADER", consts.DefaultTenantHeader)
+		required, _ := utils.GetEnvVar[bool]("TENANT_REQUIRED", false)
+
+		requested := strings.ToLower(strings.TrimSpace(c.GetHeader(header)))
+		if requested != "" && !utils.IsValidTenant(requested) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid tenant"})
+			c.Abort()
+			return
+		}
+
+		tenant, allowed := callerTenant(c, requested)
+		if !allowed {
+			log.Infof("Rejected tenant %s for %s", requested, GetUserName(c))
+			forbidden(c)
+			return
+		}
+		if tenant == "" {
+			tenant, _ = utils.GetEnvVar[string]("DEFAULT_TENANT", "")
+		}
+
+		if tenant == "" {
+			if required {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "missing tenant header " + header})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		exists, err := clients.TenantExists(c, tenant)
+		if err != nil {
+			log.Errorf("Failed to look up tenant %s: %v", tenant, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"message": "tenant catalogue unavailable"})
+			c.Abort()
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Tenant not found"})
+			c.Abort()
+			return
+		}
+
+		c.Set(consts.TenantKey, tenant)
+		c.Next()
+	}
+}
+
+// callerTenant is the tenant the caller is scoped to given the one it requested, and whether it may have it.
+func callerTenant(c *gin.Context, requested string) (string, bool) {
+	principal, authenticated := GetPrincipal(c)
+	if authenticated && principal.Tenant != "" {
+		return principal.Tenant, requested == "" || requested == principal.Tenant
+	}
+	if requested == "" || !authEnabled() || (authenticated && principal.HasRole(consts.RoleAdmin)) {
+		return requested, true
+	}
+	return "", false
+}
+
+func GetTenant(c *gin.Context) string {
+	return c.GetString(consts.TenantKey)
+}
+
+func isTenantExempt(path string) bool {
+	for _, prefix := range tenantExemptPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+package common
+
+// FieldChange is one field of a write in the audit trail. From is unset for created fields, To for removed ones.
+type FieldChange struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
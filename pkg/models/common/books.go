@@ -9,6 +9,8 @@ type Book struct {
 	Price          float64   `json:"price" validate:"required,gte=0,lte=10000"`
 	EbookAvailable bool      `json:"ebook_available" validate:"required"`
 	PublishDate    string    `json:"publish_date" validate:"required" copier:"-"`
+	CreatedAt      string    `json:"created_at" copier:"-"`
+	ISBN           string    `json:"isbn,omitempty"`
 }
 
 type PriceRange struct {
@@ -19,3 +21,19 @@ type PriceRange struct {
 type TitleUpdate struct {
 	Title string `json:"title" validate:"required"`
 }
+
+// BookMerge folds duplicates into a survivor: the survivor is updated with Survivor, then every
+// duplicate is marked with Deletion. Nothing is trashed when the survivor cannot be written.
+type BookMerge struct {
+	Survivor     map[string]interface{}
+	DuplicateIDs []string
+	Deletion     BookDeletion
+}
+
+// BookDeletion marks a book as deleted, and with MergedInto as merged into another one;
+// with nil fields it takes the book out of the trash again
+type BookDeletion struct {
+	DeletedAt  *string `json:"deleted_at"`
+	DeletedBy  *string `json:"deleted_by"`
+	MergedInto *string `json:"merged_into"`
+}
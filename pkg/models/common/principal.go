@@ -0,0 +1,48 @@
+package common
+
+import "book_service/pkg/consts"
+
+// Authentication methods
+const (
+	AuthJWT    = "jwt"
+	AuthAPIKey = "api_key"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+	Tier    string   `json:"tier"`
+	Method  string   `json:"method"`
+	// Scopes limit an API key to some route groups, none means every group
+	Scopes []string `json:"scopes,omitempty"`
+	// Tenant binds the principal to a tenant's catalogue, none means the shared one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// HasRole reports whether one of the principal's roles ranks at least as high as role.
+func (p Principal) HasRole(role string) bool {
+	required := consts.RoleLevels[role]
+	if required <= consts.RoleLevels[consts.RoleReader] {
+		return true
+	}
+	for _, granted := range p.Roles {
+		if consts.RoleLevels[granted] >= required {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the principal may call the routes of group.
+func (p Principal) HasScope(group string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range p.Scopes {
+		if scope == group {
+			return true
+		}
+	}
+	return false
+}
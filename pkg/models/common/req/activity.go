@@ -0,0 +1,81 @@
+package req
+
+import (
+	"book_service/pkg/consts"
+	face "book_service/pkg/interfaces"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	_ face.Validatable = (*Activity)(nil)
+	_ face.Validatable = (*ActivityExport)(nil)
+	_ face.Validatable = (*ActivitySummary)(nil)
+
+	streamIDPattern = regexp.MustCompile(`^\d+-\d+$`)
+)
+
+// Validate fills in the page size and normalises the method filter.
+func (a *Activity) Validate() error {
+	if !a.From.IsZero() && !a.To.IsZero() && a.From.After(a.To) {
+		return errors.New("invalid time range")
+	}
+	if a.Cursor != "" && !streamIDPattern.MatchString(a.Cursor) {
+		return errors.New("invalid cursor")
+	}
+	if a.Limit == 0 {
+		a.Limit = consts.DefaultActivityLimit
+	}
+	a.Method = strings.ToUpper(a.Method)
+	return nil
+}
+
+// Activity reads the caller's own history unless an admin names another User, or "*" for all users.
+// Times are RFC3339 and Cursor is the next_cursor of the previous page.
+type Activity struct {
+	User   string    `form:"user"`
+	Method string    `form:"method" validate:"omitempty,alpha"`
+	Path   string    `form:"path" validate:"omitempty,startswith=/"`
+	BookID string    `form:"book_id" validate:"omitempty,uuid"`
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+	Cursor string    `form:"cursor"`
+	Limit  int       `form:"limit" validate:"gte=0,lte=500"`
+}
+
+func (a *ActivityExport) Validate() error {
+	if !a.From.IsZero() && !a.To.IsZero() && a.From.After(a.To) {
+		return errors.New("invalid time range")
+	}
+	if a.Format == "" {
+		a.Format = consts.ExportNDJSON
+	}
+	return nil
+}
+
+// ActivityExport streams every action of User, or of all users with "*", between From and To
+type ActivityExport struct {
+	User   string    `form:"user"`
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+	Format string    `form:"format" validate:"omitempty,oneof=ndjson csv"`
+}
+
+func (a *ActivitySummary) Validate() error {
+	if !a.From.IsZero() && !a.To.IsZero() && a.From.After(a.To) {
+		return errors.New("invalid time range")
+	}
+	if a.Top == 0 {
+		a.Top = consts.DefaultSummaryTopBooks
+	}
+	return nil
+}
+
+type ActivitySummary struct {
+	User string    `form:"user"`
+	From time.Time `form:"from"`
+	To   time.Time `form:"to"`
+	Top  int       `form:"top" validate:"gte=0,lte=100"`
+}
@@ -1,6 +1,21 @@
 package req
 
-import "time"
+import (
+	face "book_service/pkg/interfaces"
+	"book_service/pkg/utils"
+	"errors"
+	"time"
+)
+
+var _ face.Validatable = (*AddBook)(nil)
+
+func (a *AddBook) Validate() error {
+	if a.ISBN != "" && !utils.IsValidISBN(a.ISBN) {
+		return errors.New("invalid isbn")
+	}
+	a.ISBN = utils.NormalizeISBN(a.ISBN)
+	return nil
+}
 
 type AddBook struct {
 	Title          string    `json:"title" validate:"required,min=2,max=250"`
@@ -8,4 +23,7 @@ type AddBook struct {
 	Price          float64   `json:"price" validate:"required,gte=0,lte=10000"`
 	EbookAvailable *bool     `json:"ebook_available" validate:"required"`
 	PublishDate    time.Time `json:"publish_date" validate:"required"`
+	ISBN           string    `json:"isbn,omitempty"`
+	// Force creates the book even when it duplicates existing ones
+	Force bool `form:"force" json:"-"`
 }
@@ -0,0 +1,33 @@
+package req
+
+import (
+	face "book_service/pkg/interfaces"
+	"book_service/pkg/utils"
+	"errors"
+	"time"
+)
+
+var _ face.Validatable = (*CreateAPIKey)(nil)
+
+func (k *CreateAPIKey) Validate() error {
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+	if k.Tenant != "" && !utils.IsValidTenant(k.Tenant) {
+		return errors.New("invalid tenant")
+	}
+	return nil
+}
+
+type CreateAPIKey struct {
+	Owner  string   `json:"owner" validate:"required,max=128"`
+	Roles  []string `json:"roles" validate:"dive,oneof=reader editor admin"`
+	Scopes []string `json:"scopes" validate:"dive,oneof=search read write activity admin"`
+	// Tenant binds the key to a provisioned tenant, none leaves it on the shared catalogue
+	Tenant    string     `json:"tenant"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyID struct {
+	ID string `uri:"id" binding:"required" validate:"required,uuid"`
+}
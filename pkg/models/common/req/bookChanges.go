@@ -0,0 +1,29 @@
+package req
+
+import (
+	face "book_service/pkg/interfaces"
+	"book_service/pkg/utils"
+	"errors"
+)
+
+var _ face.Validatable = (*BookChanges)(nil)
+
+// A zero MaxPrice leaves the price range open ended
+func (b *BookChanges) Validate() error {
+	if b.MaxPrice > 0 && b.MinPrice > b.MaxPrice {
+		return errors.New("invalid price range")
+	}
+	if b.LastEventID != "" && !utils.IsValidStreamID(b.LastEventID) {
+		return errors.New("invalid last_event_id")
+	}
+	return nil
+}
+
+// BookChanges filters the change stream. LastEventID stands in for the Last-Event-ID header,
+// which browsers only send when reconnecting.
+type BookChanges struct {
+	AuthorName  string  `form:"author_name" validate:"max=100"`
+	MinPrice    float64 `form:"min_price" validate:"gte=0,lte=10000"`
+	MaxPrice    float64 `form:"max_price" validate:"gte=0,lte=10000"`
+	LastEventID string  `form:"last_event_id" validate:"max=64"`
+}
@@ -0,0 +1,55 @@
+package req
+
+import (
+	face "book_service/pkg/interfaces"
+	"book_service/pkg/utils"
+	"errors"
+)
+
+var (
+	_ face.Validatable = (*BookHistory)(nil)
+	_ face.Validatable = (*BookRevisionDiff)(nil)
+	_ face.Validatable = (*RestoreBook)(nil)
+)
+
+func (b *BookHistory) Validate() error {
+	if !utils.IsValidUUID(b.ID) {
+		return errors.New("invalid uuid")
+	}
+	return nil
+}
+
+func (b *BookRevisionDiff) Validate() error {
+	if !utils.IsValidUUID(b.ID) {
+		return errors.New("invalid uuid")
+	}
+	if b.From == b.To {
+		return errors.New("from and to must be different revisions")
+	}
+	return nil
+}
+
+func (r *RestoreBook) Validate() error {
+	if !utils.IsValidUUID(r.ID) {
+		return errors.New("invalid uuid")
+	}
+	return nil
+}
+
+// BookHistory pages revisions newest first; Before is the revision of the previous page's last entry
+type BookHistory struct {
+	ID     string `uri:"id" binding:"required"`
+	Before int64  `form:"before" validate:"gte=0"`
+	Limit  int    `form:"limit" validate:"gte=0,lte=100"`
+}
+
+type BookRevisionDiff struct {
+	ID   string `uri:"id" binding:"required"`
+	From int64  `form:"from" validate:"required,gt=0"`
+	To   int64  `form:"to" validate:"required,gt=0"`
+}
+
+type RestoreBook struct {
+	ID       string `uri:"id" binding:"required"`
+	Revision int64  `form:"revision" validate:"required,gt=0"`
+}
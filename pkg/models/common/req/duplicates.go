@@ -0,0 +1,6 @@
+package req
+
+// DuplicateClusters overrides DUPLICATES_FUZZY_THRESHOLD with threshold, 0 only matching identical titles
+type DuplicateClusters struct {
+	Threshold *float64 `form:"threshold" validate:"omitempty,gte=0,lte=1"`
+}
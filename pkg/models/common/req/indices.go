@@ -0,0 +1,14 @@
+package req
+
+type IndexName struct {
+	Index string `uri:"index" binding:"required" validate:"required"`
+}
+
+type Reindex struct {
+	Index string `uri:"index" binding:"required" validate:"required"`
+	Dest  string `json:"dest" validate:"required,nefield=Index"`
+}
+
+type ReindexTask struct {
+	TaskID string `uri:"taskId" binding:"required" validate:"required"`
+}
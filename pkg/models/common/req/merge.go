@@ -0,0 +1,53 @@
+package req
+
+import (
+	"book_service/pkg/consts"
+	face "book_service/pkg/interfaces"
+	"book_service/pkg/utils"
+	"errors"
+
+	"github.com/samber/lo"
+)
+
+var _ face.Validatable = (*MergeBooks)(nil)
+
+func (m *MergeBooks) Validate() error {
+	ids := append([]string{m.SurvivorID}, m.DuplicateIDs...)
+	for _, id := range ids {
+		if !utils.IsValidUUID(id) {
+			return errors.New("invalid uuid " + id)
+		}
+	}
+	if len(lo.Uniq(ids)) != len(ids) {
+		return errors.New("survivor_id and duplicate_ids must all differ")
+	}
+
+	for field, from := range m.Fields {
+		if !lo.Contains(consts.MergeFields, field) {
+			return errors.New("field " + field + " cannot be merged")
+		}
+		if !lo.Contains(ids, from) {
+			return errors.New("field " + field + " must come from one of the merged books")
+		}
+	}
+	return nil
+}
+
+// ValidateChoices rejects a field taken from a book that has no value for it, which would erase
+// the survivor's value. Books holds the merged books by ID.
+func (m *MergeBooks) ValidateChoices(books map[string]map[string]interface{}) error {
+	for field, from := range m.Fields {
+		if books[from][field] == nil {
+			return errors.New("field " + field + " cannot be taken from book " + from + ", which has none")
+		}
+	}
+	return nil
+}
+
+// MergeBooks folds duplicate books into a survivor. Fields picks, per field, the book whose value the
+// survivor takes; other fields keep the survivor's value, or the first duplicate's when it has none.
+type MergeBooks struct {
+	SurvivorID   string            `json:"survivor_id" validate:"required"`
+	DuplicateIDs []string          `json:"duplicate_ids" validate:"required,min=1,max=50"`
+	Fields       map[string]string `json:"fields,omitempty"`
+}
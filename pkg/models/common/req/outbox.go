@@ -0,0 +1,5 @@
+package req
+
+type OutboxSink struct {
+	Name string `uri:"name" binding:"required" validate:"required,max=64"`
+}
@@ -5,6 +5,7 @@ import (
 	m "book_service/pkg/models/common"
 	"book_service/pkg/utils"
 	"errors"
+	"strings"
 )
 
 var _ face.Validatable = (*SearchBooks)(nil)
@@ -14,13 +15,38 @@ func (g *SearchBooks) Validate() error {
 	if !validRange {
 		return errors.New("invalid priceRange")
 	}
+
+	// facets=author,price and facets=author&facets=price are both accepted
+	var facets []string
+	for _, facet := range g.Facets {
+		for _, name := range strings.Split(facet, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if !utils.IsValidFacet(name) {
+				return errors.New("invalid facet " + name)
+			}
+			facets = append(facets, name)
+		}
+	}
+	g.Facets = facets
+
+	for name, values := range g.FacetFilters {
+		for _, value := range values {
+			if !utils.IsValidFacetValue(name, value) {
+				return errors.New("invalid facet filter " + name + "=" + value)
+			}
+		}
+	}
 	return nil
 }
 
 type SearchBooks struct {
-	Title      string       `json:"title,omitempty"`
-	AuthorName string       `json:"author_name,omitempty"`
-	PriceRange m.PriceRange `json:"price_range,omitempty"`
-	Size       int          `form:"size" validate:"gte=0,lte=100"`
-	From       int          `form:"from" validate:"gte=0,lte=9999999"`
+	Title        string              `json:"title,omitempty"`
+	AuthorName   string              `json:"author_name,omitempty"`
+	PriceRange   m.PriceRange        `json:"price_range,omitempty"`
+	Size         int                 `form:"size" validate:"gte=0,lte=100"`
+	From         int                 `form:"from" validate:"gte=0,lte=9999999"`
+	Facets       []string            `form:"facets" json:"facets,omitempty"`
+	FacetFilters map[string][]string `json:"facet_filters,omitempty"`
 }
@@ -0,0 +1,24 @@
+package req
+
+import (
+	face "book_service/pkg/interfaces"
+	"errors"
+)
+
+var _ face.Validatable = (*StoreStats)(nil)
+
+// A zero MaxPrice leaves the price range open ended
+func (s *StoreStats) Validate() error {
+	if s.MaxPrice > 0 && s.MinPrice > s.MaxPrice {
+		return errors.New("invalid price range")
+	}
+	return nil
+}
+
+type StoreStats struct {
+	Title      string  `form:"title"`
+	AuthorName string  `form:"author_name"`
+	MinPrice   float64 `form:"min_price" validate:"gte=0,lte=10000"`
+	MaxPrice   float64 `form:"max_price" validate:"gte=0,lte=10000"`
+	Top        int     `form:"top" validate:"gte=0,lte=100"`
+}
@@ -0,0 +1,20 @@
+package req
+
+import (
+	face "book_service/pkg/interfaces"
+	"book_service/pkg/utils"
+	"errors"
+)
+
+var _ face.Validatable = (*CreateTenant)(nil)
+
+func (t *CreateTenant) Validate() error {
+	if !utils.IsValidTenant(t.ID) {
+		return errors.New("invalid tenant")
+	}
+	return nil
+}
+
+type CreateTenant struct {
+	ID string `json:"id" validate:"required"`
+}
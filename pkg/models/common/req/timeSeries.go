@@ -0,0 +1,97 @@
+package req
+
+import (
+	"book_service/pkg/consts"
+	face "book_service/pkg/interfaces"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	_ face.Validatable = (*TimeSeries)(nil)
+
+	utcOffsetPattern = regexp.MustCompile(`^[+-]\d{2}:\d{2}$`)
+)
+
+// Validate fills in the defaults: publish_date by month in UTC, counting books.
+func (t *TimeSeries) Validate() error {
+	if t.Field == "" {
+		t.Field = "publish_date"
+	}
+	if t.Interval == "" {
+		t.Interval = "month"
+	}
+	if t.TimeZone == "" {
+		t.TimeZone = "UTC"
+	}
+	if !utcOffsetPattern.MatchString(t.TimeZone) {
+		if _, err := time.LoadLocation(t.TimeZone); err != nil {
+			return errors.New("invalid timezone " + t.TimeZone)
+		}
+	}
+
+	if err := t.validateWindow(); err != nil {
+		return err
+	}
+
+	var metrics []string
+	for _, metric := range t.Metrics {
+		for _, name := range strings.Split(metric, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if _, ok := consts.TimeSeriesMetrics[name]; !ok && name != consts.MetricCount {
+				return errors.New("invalid metric " + name)
+			}
+			metrics = append(metrics, name)
+		}
+	}
+	if len(metrics) == 0 {
+		metrics = []string{consts.MetricCount}
+	}
+	t.Metrics = metrics
+	return nil
+}
+
+// validateWindow requires a bounded window for the intervals capped in consts.TimeSeriesMaxSpans.
+func (t *TimeSeries) validateWindow() error {
+	var from, to time.Time
+	var err error
+	if t.From != "" {
+		if from, err = time.Parse(time.DateOnly, t.From); err != nil {
+			return errors.New("invalid from date " + t.From)
+		}
+	}
+	if t.To != "" {
+		if to, err = time.Parse(time.DateOnly, t.To); err != nil {
+			return errors.New("invalid to date " + t.To)
+		}
+	}
+	if t.From != "" && t.To != "" && to.Before(from) {
+		return errors.New("to must not be before from")
+	}
+
+	maxSpan, capped := consts.TimeSeriesMaxSpans[t.Interval]
+	if !capped {
+		return nil
+	}
+	if t.From == "" || t.To == "" {
+		return errors.New("from and to are required for interval " + t.Interval)
+	}
+	if to.Sub(from) > maxSpan {
+		return fmt.Errorf("interval %s covers at most %d days", t.Interval, int(maxSpan.Hours()/24))
+	}
+	return nil
+}
+
+type TimeSeries struct {
+	Field    string   `form:"field" validate:"omitempty,oneof=publish_date created_at"`
+	Interval string   `form:"interval" validate:"omitempty,oneof=day week month quarter year"`
+	TimeZone string   `form:"timezone"`
+	Metrics  []string `form:"metrics"`
+	From     string   `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To       string   `form:"to" validate:"omitempty,datetime=2006-01-02"`
+}
@@ -0,0 +1,25 @@
+package req
+
+import (
+	face "book_service/pkg/interfaces"
+	"book_service/pkg/utils"
+	"errors"
+)
+
+var _ face.Validatable = (*TrashedBook)(nil)
+
+func (t *TrashedBook) Validate() error {
+	if !utils.IsValidUUID(t.ID) {
+		return errors.New("invalid uuid")
+	}
+	return nil
+}
+
+type Trash struct {
+	From int `form:"from" validate:"gte=0,lte=10000"`
+	Size int `form:"size" validate:"gte=0,lte=100"`
+}
+
+type TrashedBook struct {
+	ID string `uri:"id" binding:"required"`
+}
@@ -0,0 +1,34 @@
+package req
+
+import (
+	face "book_service/pkg/interfaces"
+	"errors"
+	"net/url"
+)
+
+var _ face.Validatable = (*CreateWebhook)(nil)
+
+func (w *CreateWebhook) Validate() error {
+	target, err := url.Parse(w.URL)
+	if err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+		return errors.New("url must be an absolute http or https url")
+	}
+	return nil
+}
+
+// CreateWebhook subscribes a URL to book events; no Events means all of them and no Tenant every tenant
+type CreateWebhook struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"dive,oneof=book.created book.updated book.deleted"`
+	Tenant string   `json:"tenant" validate:"omitempty,max=48"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+type WebhookID struct {
+	ID string `uri:"id" binding:"required" validate:"required,uuid"`
+}
+
+type WebhookDelivery struct {
+	ID         string `uri:"id" binding:"required" validate:"required,uuid"`
+	DeliveryID string `uri:"deliveryId" binding:"required" validate:"required,uuid"`
+}
@@ -0,0 +1,22 @@
+package res
+
+import "time"
+
+// APIKey is a managed key as shown to admins, without its hash
+type APIKey struct {
+	ID         string     `json:"id"`
+	Prefix     string     `json:"prefix"`
+	Owner      string     `json:"owner"`
+	Roles      []string   `json:"roles"`
+	Scopes     []string   `json:"scopes"`
+	Tenant     string     `json:"tenant,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// IssuedAPIKey carries the raw key, which is only returned when it is created or rotated
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -1,11 +1,29 @@
 package res
 
 import (
+	"book_service/pkg/models/common"
+	"book_service/pkg/utils"
+
 	"github.com/google/uuid"
 )
 
 type AddBook struct {
 	ID uuid.UUID `json:"id"`
+	// Duplicates are the existing books the new one duplicates, when they do not prevent creating it
+	Duplicates []DuplicateMatch `json:"duplicates,omitempty"`
+}
+
+// DuplicateMatch is an existing book a new one duplicates, and why
+type DuplicateMatch struct {
+	utils.BookKey
+	Reason     string  `json:"reason"`
+	Similarity float64 `json:"similarity"`
+}
+
+type DuplicateClusters struct {
+	Clusters  []utils.DuplicateCluster `json:"clusters"`
+	Scanned   int                      `json:"scanned"`
+	Threshold float64                  `json:"threshold"`
 }
 
 type UpdateBook struct {
@@ -15,3 +33,49 @@ type UpdateBook struct {
 type DeleteBook struct {
 	ID uuid.UUID `json:"message"`
 }
+
+// SearchBooks is the page of books a search found, with the bucket counts of the facets it asked for
+type SearchBooks struct {
+	Hits   []map[string]interface{}  `json:"hits"`
+	Facets map[string][]utils.Bucket `json:"facets,omitempty"`
+}
+
+// RevisionDiff lists the fields that differ between the snapshots of two revisions of a book
+type RevisionDiff struct {
+	ID      string                        `json:"id"`
+	From    int64                         `json:"from"`
+	To      int64                         `json:"to"`
+	Changes map[string]common.FieldChange `json:"changes"`
+}
+
+type RestoreBook struct {
+	ID       uuid.UUID `json:"id"`
+	Revision int64     `json:"revision"`
+}
+
+// TrashedBook is a deleted book, purged for good at PurgeAt
+type TrashedBook struct {
+	ID        string                 `json:"id"`
+	DeletedAt string                 `json:"deleted_at"`
+	DeletedBy string                 `json:"deleted_by,omitempty"`
+	PurgeAt   string                 `json:"purge_at,omitempty"`
+	Book      map[string]interface{} `json:"book"`
+}
+
+type Trash struct {
+	Books []TrashedBook `json:"books"`
+	Total int           `json:"total"`
+}
+
+// MergeBooks is a queued merge: the survivor's changed fields, and the books now redirecting to it
+type MergeBooks struct {
+	ID      uuid.UUID                     `json:"id"`
+	Merged  []string                      `json:"merged"`
+	Changes map[string]common.FieldChange `json:"changes"`
+}
+
+// MergedBook answers a read of a book merged into another one
+type MergedBook struct {
+	Message    string `json:"message"`
+	MergedInto string `json:"merged_into"`
+}
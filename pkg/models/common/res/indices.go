@@ -0,0 +1,12 @@
+package res
+
+type Reindex struct {
+	TaskID string `json:"task_id"`
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+}
+
+type IndexAction struct {
+	Index  string `json:"index"`
+	Action string `json:"action"`
+}
@@ -0,0 +1,80 @@
+package res
+
+import "book_service/pkg/utils"
+
+type PriceStats struct {
+	Min         *float64               `json:"min"`
+	Avg         *float64               `json:"avg"`
+	Max         *float64               `json:"max"`
+	Percentiles map[string]interface{} `json:"percentiles"`
+}
+
+type AuthorCount struct {
+	Author string `json:"author"`
+	Books  int    `json:"books"`
+}
+
+type StoreStats struct {
+	TotalBooks        int           `json:"total_books"`
+	DistinctAuthors   int           `json:"distinct_authors"`
+	Price             PriceStats    `json:"price"`
+	EbookCount        int           `json:"ebook_count"`
+	EbookShare        float64       `json:"ebook_share"`
+	OldestPublishDate *string       `json:"oldest_publish_date"`
+	NewestPublishDate *string       `json:"newest_publish_date"`
+	TopAuthors        []AuthorCount `json:"top_authors"`
+}
+
+// NewStoreStats maps the parsed "StoreStats" aggregation group onto the store statistics response.
+func NewStoreStats(totalBooks int, stats utils.AggregationResult) StoreStats {
+	storeStats := StoreStats{
+		TotalBooks: totalBooks,
+		Price: PriceStats{
+			Min: floatValue(stats["min_price"]),
+			Avg: floatValue(stats["avg_price"]),
+			Max: floatValue(stats["max_price"]),
+		},
+		OldestPublishDate: stringValue(stats["oldest_publish_date"]),
+		NewestPublishDate: stringValue(stats["newest_publish_date"]),
+		TopAuthors:        []AuthorCount{},
+	}
+
+	if distinctAuthors, ok := stats["distinct_authors"].(int); ok {
+		storeStats.DistinctAuthors = distinctAuthors
+	}
+	if percentiles, ok := stats["price_percentiles"].(map[string]interface{}); ok {
+		storeStats.Price.Percentiles = percentiles
+	}
+
+	ebookBuckets, _ := stats["ebook_available"].([]utils.Bucket)
+	for _, bucket := range ebookBuckets {
+		if bucket.KeyAsString == "true" {
+			storeStats.EbookCount = bucket.DocCount
+		}
+	}
+	if totalBooks > 0 {
+		storeStats.EbookShare = float64(storeStats.EbookCount) / float64(totalBooks)
+	}
+
+	authorBuckets, _ := stats["top_authors"].([]utils.Bucket)
+	for _, bucket := range authorBuckets {
+		author, _ := bucket.Key.(string)
+		storeStats.TopAuthors = append(storeStats.TopAuthors, AuthorCount{Author: author, Books: bucket.DocCount})
+	}
+
+	return storeStats
+}
+
+func floatValue(value interface{}) *float64 {
+	if number, ok := value.(float64); ok {
+		return &number
+	}
+	return nil
+}
+
+func stringValue(value interface{}) *string {
+	if str, ok := value.(string); ok {
+		return &str
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package res
+
+// Tenant is a provisioned tenant and its indices
+type Tenant struct {
+	ID      string   `json:"id"`
+	Indices []string `json:"indices"`
+}
@@ -0,0 +1,45 @@
+package res
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+
+	"github.com/samber/lo"
+)
+
+type TimeSeriesPoint struct {
+	Date       string   `json:"date"`
+	Count      *int     `json:"count,omitempty"`
+	AvgPrice   *float64 `json:"avg_price,omitempty"`
+	EbookCount *int     `json:"ebook_count,omitempty"`
+}
+
+type TimeSeries struct {
+	Field    string            `json:"field"`
+	Interval string            `json:"interval"`
+	TimeZone string            `json:"timezone"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+// NewTimeSeriesPoints keeps only the requested metrics of each date histogram bucket.
+func NewTimeSeriesPoints(buckets []utils.Bucket, metrics []string) []TimeSeriesPoint {
+	points := make([]TimeSeriesPoint, 0, len(buckets))
+	for _, bucket := range buckets {
+		point := TimeSeriesPoint{Date: bucket.KeyAsString}
+		if lo.Contains(metrics, consts.MetricCount) {
+			count := bucket.DocCount
+			point.Count = &count
+		}
+		if lo.Contains(metrics, consts.MetricAvgPrice) {
+			point.AvgPrice = floatValue(bucket.Aggregations[consts.MetricAvgPrice])
+		}
+		if lo.Contains(metrics, consts.MetricEbookCount) {
+			if ebooks := floatValue(bucket.Aggregations[consts.MetricEbookCount]); ebooks != nil {
+				ebookCount := int(*ebooks)
+				point.EbookCount = &ebookCount
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
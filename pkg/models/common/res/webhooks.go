@@ -0,0 +1,18 @@
+package res
+
+import "time"
+
+// Webhook is a subscription as listed to admins, without its secret
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Tenant    string    `json:"tenant,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreatedWebhook carries the signing secret, which is only returned on creation
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
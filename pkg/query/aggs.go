@@ -0,0 +1,72 @@
+package query
+
+import "book_service/pkg/consts"
+
+// AggregationBody renders an aggregation config, including its sub-aggregations, as an Elasticsearch aggregation.
+func AggregationBody(aggConfig consts.AggregationConfig) map[string]interface{} {
+	params := map[string]interface{}{
+		"field": aggConfig.Field,
+	}
+
+	switch aggConfig.Type {
+	case consts.AggMin, consts.AggMax:
+		if aggConfig.Format != "" {
+			params["format"] = aggConfig.Format
+		}
+	case consts.AggPercentiles:
+		if len(aggConfig.Percents) > 0 {
+			params["percents"] = aggConfig.Percents
+		}
+	case consts.AggTerms:
+		if aggConfig.Size > 0 {
+			params["size"] = aggConfig.Size
+		}
+	case consts.AggHistogram:
+		params["interval"] = aggConfig.Interval
+	case consts.AggDateHistogram:
+		params["calendar_interval"] = aggConfig.CalendarInterval
+		if aggConfig.TimeZone != "" {
+			params["time_zone"] = aggConfig.TimeZone
+		}
+		if aggConfig.Format != "" {
+			params["format"] = aggConfig.Format
+		}
+	case consts.AggRange, consts.AggDateRange:
+		if aggConfig.Format != "" {
+			params["format"] = aggConfig.Format
+		}
+		ranges := make([]map[string]interface{}, 0, len(aggConfig.Ranges))
+		for _, r := range aggConfig.Ranges {
+			bound := map[string]interface{}{}
+			if r.Key != "" {
+				bound["key"] = r.Key
+			}
+			if r.From != nil {
+				bound["from"] = r.From
+			}
+			if r.To != nil {
+				bound["to"] = r.To
+			}
+			ranges = append(ranges, bound)
+		}
+		params["ranges"] = ranges
+	}
+
+	if aggConfig.MinDocCount != nil {
+		params["min_doc_count"] = *aggConfig.MinDocCount
+	}
+
+	body := map[string]interface{}{
+		aggConfig.Type: params,
+	}
+
+	if len(aggConfig.SubAggregations) > 0 {
+		subAggs := make(map[string]interface{}, len(aggConfig.SubAggregations))
+		for name, subConfig := range aggConfig.SubAggregations {
+			subAggs[name] = AggregationBody(subConfig)
+		}
+		body["aggs"] = subAggs
+	}
+
+	return body
+}
@@ -13,6 +13,9 @@ type Builder struct {
 	priceMin     *float64
 	priceMax     *float64
 	aggregations map[string]interface{}
+	postFilter   map[string]interface{}
+	dateRanges   []map[string]interface{}
+	deleted      *bool
 }
 
 func NewQueryBuilder() *Builder {
@@ -46,11 +49,11 @@ func (qb *Builder) AuthorName(a string) *Builder {
 }
 
 func (qb *Builder) PriceRange(min, max float64) *Builder {
-	unseted := min == max && min == 0
+	unseted := max == 0
 	if unseted {
+		// Open ended, an infinite upper bound cannot be encoded as JSON
 		qb.priceMin = &min
-		highestPrice := consts.HighestBookPrice
-		qb.priceMax = &highestPrice
+		qb.priceMax = nil
 		return qb
 	}
 	qb.priceMin = &min
@@ -58,6 +61,20 @@ func (qb *Builder) PriceRange(min, max float64) *Builder {
 	return qb
 }
 
+// ExcludeDeleted leaves out the books in the trash.
+func (qb *Builder) ExcludeDeleted() *Builder {
+	deleted := false
+	qb.deleted = &deleted
+	return qb
+}
+
+// OnlyDeleted only matches the books in the trash.
+func (qb *Builder) OnlyDeleted() *Builder {
+	deleted := true
+	qb.deleted = &deleted
+	return qb
+}
+
 func (qb *Builder) DistinctAuthors() *Builder {
 	group := "BookStats"
 	return qb.AddAggregation(group, "distinct_authors")
@@ -68,6 +85,33 @@ func (qb *Builder) TotalBooks() *Builder {
 	return qb.AddAggregation(group, "total_books")
 }
 
+// StoreStats adds every store statistic, listing the topAuthors most prolific authors.
+func (qb *Builder) StoreStats(topAuthors int) *Builder {
+	group := "StoreStats"
+	for aggName := range consts.AggregationConfigs[group] {
+		qb.AddAggregation(group, aggName)
+	}
+
+	topConfig := consts.AggregationConfigs[group]["top_authors"]
+	topConfig.Size = topAuthors
+	return qb.AddAggregationConfig("top_authors", topConfig)
+}
+
+func (qb *Builder) BooksPerAuthor() *Builder {
+	group := "BookDistributions"
+	return qb.AddAggregation(group, "books_per_author")
+}
+
+func (qb *Builder) PriceDistribution() *Builder {
+	group := "BookDistributions"
+	return qb.AddAggregation(group, "price_distribution")
+}
+
+func (qb *Builder) BooksPerYear() *Builder {
+	group := "BookDistributions"
+	return qb.AddAggregation(group, "books_per_year")
+}
+
 func (qb *Builder) Build() map[string]interface{} {
 	var mustClauses []map[string]interface{}
 
@@ -95,22 +139,41 @@ func (qb *Builder) Build() map[string]interface{} {
 		})
 	}
 
-	if qb.priceMin != nil && qb.priceMax != nil {
+	if qb.priceMin != nil {
+		priceRange := map[string]interface{}{
+			"gte": *qb.priceMin,
+		}
+		if qb.priceMax != nil {
+			priceRange["lte"] = *qb.priceMax
+		}
 		mustClauses = append(mustClauses, map[string]interface{}{
 			"range": map[string]interface{}{
-				"price": map[string]interface{}{
-					"gte": *qb.priceMin,
-					"lte": *qb.priceMax,
-				},
+				"price": priceRange,
 			},
 		})
 	}
 
+	mustClauses = append(mustClauses, qb.dateRanges...)
+
+	boolQuery := map[string]interface{}{
+		"must": mustClauses,
+	}
+	if qb.deleted != nil {
+		inTrash := map[string]interface{}{
+			"exists": map[string]interface{}{
+				"field": consts.DeletedAtField,
+			},
+		}
+		if *qb.deleted {
+			boolQuery["must"] = append(mustClauses, inTrash)
+		} else {
+			boolQuery["must_not"] = []map[string]interface{}{inTrash}
+		}
+	}
+
 	query := map[string]interface{}{
 		"query": map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must": mustClauses,
-			},
+			"bool": boolQuery,
 		},
 	}
 
@@ -118,17 +181,17 @@ func (qb *Builder) Build() map[string]interface{} {
 		query["aggs"] = qb.aggregations
 	}
 
+	if qb.postFilter != nil {
+		query["post_filter"] = qb.postFilter
+	}
+
 	return query
 }
 
 func (qb *Builder) AddAggregation(aggGroup, aggName string) *Builder {
 	if groupConfig, ok := consts.AggregationConfigs[aggGroup]; ok {
 		if aggConfig, ok := groupConfig[aggName]; ok {
-			qb.aggregations[aggName] = map[string]interface{}{
-				aggConfig.Type: map[string]interface{}{
-					"field": aggConfig.Field,
-				},
-			}
+			qb.AddAggregationConfig(aggName, aggConfig)
 		} else {
 			log.Printf("Warning: Aggregation '%s' not found in group '%s'", aggName, aggGroup)
 		}
@@ -137,3 +200,9 @@ func (qb *Builder) AddAggregation(aggGroup, aggName string) *Builder {
 	}
 	return qb
 }
+
+// AddAggregationConfig adds an aggregation that is not part of a predefined group.
+func (qb *Builder) AddAggregationConfig(aggName string, aggConfig consts.AggregationConfig) *Builder {
+	qb.aggregations[aggName] = AggregationBody(aggConfig)
+	return qb
+}
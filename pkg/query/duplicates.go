@@ -0,0 +1,41 @@
+package query
+
+import "book_service/pkg/consts"
+
+// DuplicateCandidates finds the books outside the trash that may duplicate a new one: those with its ISBN,
+// and those by its author whose title has all of its words, or with fuzzy, only some of them allowing typos.
+// The candidates are narrowed down by utils.MatchDuplicate.
+func DuplicateCandidates(title, authorName, isbn string, fuzzy bool) map[string]interface{} {
+	titleMatch := map[string]interface{}{"query": title, "operator": "and"}
+	if fuzzy {
+		titleMatch = map[string]interface{}{"query": title, "fuzziness": "AUTO", "minimum_should_match": "50%"}
+	}
+
+	should := []map[string]interface{}{
+		{
+			"bool": map[string]interface{}{
+				"must": []map[string]interface{}{
+					{"match": map[string]interface{}{"author_name": map[string]interface{}{"query": authorName, "operator": "and"}}},
+					{"match": map[string]interface{}{"title": titleMatch}},
+				},
+			},
+		},
+	}
+	if isbn != "" {
+		should = append(should, map[string]interface{}{
+			"term": map[string]interface{}{consts.ISBNField: isbn},
+		})
+	}
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+				"must_not": []map[string]interface{}{
+					{"exists": map[string]interface{}{"field": consts.DeletedAtField}},
+				},
+			},
+		},
+	}
+}
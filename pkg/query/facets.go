@@ -0,0 +1,104 @@
+package query
+
+import (
+	"book_service/pkg/consts"
+	"sort"
+)
+
+// Facets requests bucket counts for the named facets and narrows the hits to the selected buckets.
+// Selections go to post_filter, and each facet's counts only apply the selections of the other facets,
+// so choosing a bucket does not collapse the counts of its own facet.
+func (qb *Builder) Facets(names []string, selections map[string][]string) *Builder {
+	filters := make(map[string]map[string]interface{}, len(selections))
+	for name, values := range selections {
+		if filter := facetFilter(name, values); filter != nil {
+			filters[name] = filter
+		}
+	}
+
+	if len(filters) > 0 {
+		qb.postFilter = boolFilter(filters, "")
+	}
+
+	for _, name := range names {
+		facetConfig, ok := consts.FacetConfigs[name]
+		if !ok {
+			continue
+		}
+		qb.aggregations[name] = map[string]interface{}{
+			"filter": boolFilter(filters, name),
+			"aggs": map[string]interface{}{
+				name: AggregationBody(facetConfig),
+			},
+		}
+	}
+
+	return qb
+}
+
+// boolFilter combines every facet filter except the excluded one; with nothing to combine it matches all.
+func boolFilter(filters map[string]map[string]interface{}, exclude string) map[string]interface{} {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		if name != exclude {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+	sort.Strings(names)
+
+	clauses := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		clauses = append(clauses, filters[name])
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"filter": clauses},
+	}
+}
+
+func facetFilter(name string, values []string) map[string]interface{} {
+	facetConfig, ok := consts.FacetConfigs[name]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+
+	if facetConfig.Type == consts.AggTerms {
+		return map[string]interface{}{
+			"terms": map[string]interface{}{facetConfig.Field: values},
+		}
+	}
+
+	var should []map[string]interface{}
+	for _, r := range facetConfig.Ranges {
+		for _, value := range values {
+			if r.Key != value {
+				continue
+			}
+			bounds := map[string]interface{}{}
+			if r.From != nil {
+				bounds["gte"] = r.From
+			}
+			if r.To != nil {
+				bounds["lt"] = r.To
+			}
+			if facetConfig.Format != "" {
+				bounds["format"] = facetConfig.Format
+			}
+			should = append(should, map[string]interface{}{
+				"range": map[string]interface{}{facetConfig.Field: bounds},
+			})
+		}
+	}
+	if len(should) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	}
+}
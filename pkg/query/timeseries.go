@@ -0,0 +1,51 @@
+package query
+
+import "book_service/pkg/consts"
+
+const TimeSeriesAggregation = "series"
+
+// TimeSeriesConfig describes the date histogram built by TimeSeries, so the response can be parsed with it.
+func TimeSeriesConfig(field, interval, timeZone string, metrics []string) consts.AggregationConfig {
+	minDocCount := 0
+	aggConfig := consts.AggregationConfig{
+		Field:            field,
+		Type:             consts.AggDateHistogram,
+		CalendarInterval: interval,
+		TimeZone:         timeZone,
+		MinDocCount:      &minDocCount,
+		SubAggregations:  map[string]consts.AggregationConfig{},
+	}
+	for _, metric := range metrics {
+		if metricConfig, ok := consts.TimeSeriesMetrics[metric]; ok {
+			aggConfig.SubAggregations[metric] = metricConfig
+		}
+	}
+	return aggConfig
+}
+
+// TimeSeries buckets the matching books per calendar interval of a date field.
+func (qb *Builder) TimeSeries(field, interval, timeZone string, metrics []string) *Builder {
+	return qb.AddAggregationConfig(TimeSeriesAggregation, TimeSeriesConfig(field, interval, timeZone, metrics))
+}
+
+// DateRange keeps books whose date field falls within [from, to]; empty bounds are left open.
+// Bounds without an offset are read in timeZone, so they line up with the buckets of TimeSeries.
+func (qb *Builder) DateRange(field, from, to, timeZone string) *Builder {
+	if from == "" && to == "" {
+		return qb
+	}
+	bounds := map[string]interface{}{}
+	if from != "" {
+		bounds["gte"] = from
+	}
+	if to != "" {
+		bounds["lte"] = to
+	}
+	if timeZone != "" {
+		bounds["time_zone"] = timeZone
+	}
+	qb.dateRanges = append(qb.dateRanges, map[string]interface{}{
+		"range": map[string]interface{}{field: bounds},
+	})
+	return qb
+}
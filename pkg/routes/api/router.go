@@ -9,4 +9,5 @@ import (
 func ApiRouter(router *gin.Engine) {
 	api := router.Group("/api")
 	v1.RegisterBooksRoutes(api)
+	v1.RegisterTrashRoutes(api)
 }
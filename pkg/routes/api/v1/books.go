@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"book_service/pkg/consts"
 	handlers "book_service/pkg/handlers/v1"
 	mw "book_service/pkg/middlewares"
 	"book_service/pkg/models/common/req"
@@ -11,10 +12,16 @@ import (
 func RegisterBooksRoutes(rgp *gin.RouterGroup) {
 	v1 := rgp.Group("/v1/books")
 	{
-		v1.GET("/:id", mw.Validation[req.GetBook](), handlers.GetBookById)
-		v1.PUT("/:id", mw.Validation[req.UpdateBook](), handlers.UpdateBook) // why just title
-		v1.DELETE("/:id", mw.Validation[req.DeleteBook](), handlers.DeleteBook)
-		v1.GET("/search", mw.Validation[req.SearchBooks](), handlers.SearchBooks) // the good pattern for search is to put it into body due to size
-		v1.POST("/", mw.Validation[req.AddBook](), handlers.CreateBook)
+		v1.GET("/:id", mw.Audit(consts.RouteRead), mw.RequireAuth(consts.RouteRead), mw.RateLimit(consts.RouteRead), mw.Validation[req.GetBook](), handlers.GetBookById)
+		v1.PUT("/:id", mw.Audit(consts.RouteWrite), mw.RequireAuth(consts.RouteWrite), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteWrite), mw.Idempotency(), mw.Validation[req.UpdateBook](), handlers.UpdateBook) // why just title
+		v1.DELETE("/:id", mw.Audit(consts.RouteWrite), mw.RequireAuth(consts.RouteWrite), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteWrite), mw.Idempotency(), mw.Validation[req.DeleteBook](), handlers.DeleteBook)
+		v1.GET("/search", mw.Audit(consts.RouteSearch), mw.RequireAuth(consts.RouteSearch), mw.RateLimit(consts.RouteSearch), mw.Validation[req.SearchBooks](), handlers.SearchBooks) // the good pattern for search is to put it into body due to size
+		v1.GET("/_changes", mw.Audit(consts.RouteRead), mw.RequireAuth(consts.RouteRead), mw.RateLimit(consts.RouteRead), mw.Validation[req.BookChanges](), handlers.StreamBookChanges)
+		v1.POST("/_merge", mw.Audit(consts.RouteWrite), mw.RequireAuth(consts.RouteWrite), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteWrite), mw.Idempotency(), mw.Validation[req.MergeBooks](), handlers.MergeBooks)
+		v1.GET("/_duplicates", mw.Audit(consts.RouteSearch), mw.RequireAuth(consts.RouteSearch), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteSearch), mw.Validation[req.DuplicateClusters](), handlers.ListDuplicateClusters)
+		v1.GET("/:id/history", mw.Audit(consts.RouteRead), mw.RequireAuth(consts.RouteRead), mw.RateLimit(consts.RouteRead), mw.Validation[req.BookHistory](), handlers.GetBookHistory)
+		v1.GET("/:id/history/diff", mw.Audit(consts.RouteRead), mw.RequireAuth(consts.RouteRead), mw.RateLimit(consts.RouteRead), mw.Validation[req.BookRevisionDiff](), handlers.DiffBookRevisions)
+		v1.POST("/:id/restore", mw.Audit(consts.RouteWrite), mw.RequireAuth(consts.RouteWrite), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteWrite), mw.Idempotency(), mw.Validation[req.RestoreBook](), handlers.RestoreBook)
+		v1.POST("/", mw.Audit(consts.RouteWrite), mw.RequireAuth(consts.RouteWrite), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteWrite), mw.Idempotency(), mw.Validation[req.AddBook](), handlers.CreateBook)
 	}
 }
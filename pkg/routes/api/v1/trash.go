@@ -0,0 +1,19 @@
+package v1
+
+import (
+	"book_service/pkg/consts"
+	handlers "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTrashRoutes(rgp *gin.RouterGroup) {
+	v1 := rgp.Group("/v1/trash")
+	{
+		v1.GET("", mw.Audit(consts.RouteRead), mw.RequireAuth(consts.RouteRead), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteRead), mw.Validation[req.Trash](), handlers.ListTrash)
+		v1.POST("/:id/restore", mw.Audit(consts.RouteWrite), mw.RequireAuth(consts.RouteWrite), mw.RequireRole(consts.RoleEditor), mw.RateLimit(consts.RouteWrite), mw.Idempotency(), mw.Validation[req.TrashedBook](), handlers.UndeleteBook)
+		v1.DELETE("/:id", mw.Audit(consts.RouteWrite), mw.RequireAuth(consts.RouteWrite), mw.RequireRole(consts.RoleAdmin), mw.RateLimit(consts.RouteWrite), mw.Idempotency(), mw.Validation[req.TrashedBook](), handlers.PurgeBook)
+	}
+}
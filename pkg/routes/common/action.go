@@ -1,25 +1,19 @@
 package common
 
 import (
-	"book_service/pkg/clients"
 	"book_service/pkg/consts"
-	"book_service/pkg/middlewares"
-	"net/http"
+	v1 "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
 
 	"github.com/gin-gonic/gin"
 )
 
 func ActionRoutes(router *gin.Engine) {
-	actionGroup := router.Group("/" + consts.ActionRoute)
+	actionGroup := router.Group("/"+consts.ActionRoute, mw.Audit(consts.RouteActivity), mw.RequireAuth(consts.RouteActivity))
 	{
-		actionGroup.GET("", func(c *gin.Context) {
-			username := middlewares.GetUserName(c)
-			userActions, err := clients.GetLastActions(username)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-				return
-			}
-			c.JSON(http.StatusOK, userActions)
-		})
+		actionGroup.GET("", mw.Validation[req.Activity](), v1.GetActivity)
+		actionGroup.GET("/export", mw.Validation[req.ActivityExport](), v1.ExportActivity)
+		actionGroup.GET("/summary", mw.Validation[req.ActivitySummary](), v1.GetActivitySummary)
 	}
 }
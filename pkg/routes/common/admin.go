@@ -0,0 +1,39 @@
+package common
+
+import (
+	"book_service/pkg/consts"
+	v1 "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(router *gin.Engine) {
+	adminGroup := router.Group("/"+consts.AdminRoute, mw.Audit(consts.AdminRoute), mw.AdminAuth())
+	{
+		adminGroup.GET("/indices", v1.ListIndices)
+		adminGroup.GET("/indices/:index", mw.Validation[req.IndexName](), v1.GetIndex)
+		adminGroup.POST("/indices/:index/_reindex", mw.Validation[req.Reindex](), v1.ReindexIndex)
+		adminGroup.POST("/indices/:index/_refresh", mw.Validation[req.IndexName](), v1.RefreshIndex)
+		adminGroup.POST("/indices/:index/_flush", mw.Validation[req.IndexName](), v1.FlushIndex)
+		adminGroup.POST("/indices/:index/_close", mw.Validation[req.IndexName](), v1.CloseIndex)
+		adminGroup.POST("/indices/:index/_open", mw.Validation[req.IndexName](), v1.OpenIndex)
+		adminGroup.POST("/tenants", mw.Validation[req.CreateTenant](), v1.CreateTenant)
+		adminGroup.GET("/tasks/:taskId", mw.Validation[req.ReindexTask](), v1.GetReindexTask)
+		adminGroup.GET("/cache", v1.GetCacheStats)
+		adminGroup.GET("/actions", v1.GetActionStats)
+		adminGroup.POST("/apikeys", mw.Validation[req.CreateAPIKey](), v1.CreateAPIKey)
+		adminGroup.GET("/apikeys", v1.ListAPIKeys)
+		adminGroup.POST("/apikeys/:id/_rotate", mw.Validation[req.APIKeyID](), v1.RotateAPIKey)
+		adminGroup.DELETE("/apikeys/:id", mw.Validation[req.APIKeyID](), v1.RevokeAPIKey)
+		adminGroup.POST("/webhooks", mw.Validation[req.CreateWebhook](), v1.CreateWebhook)
+		adminGroup.GET("/webhooks", v1.ListWebhooks)
+		adminGroup.GET("/webhooks/:id", mw.Validation[req.WebhookID](), v1.GetWebhook)
+		adminGroup.DELETE("/webhooks/:id", mw.Validation[req.WebhookID](), v1.DeleteWebhook)
+		adminGroup.GET("/webhooks/:id/deliveries", mw.Validation[req.WebhookID](), v1.ListWebhookDeliveries)
+		adminGroup.POST("/webhooks/:id/deliveries/:deliveryId/_redeliver", mw.Validation[req.WebhookDelivery](), v1.RedeliverWebhook)
+		adminGroup.GET("/outbox", v1.GetOutboxStatus)
+		adminGroup.DELETE("/outbox/sinks/:name", mw.Validation[req.OutboxSink](), v1.RemoveOutboxSink)
+	}
+}
@@ -1,14 +1,29 @@
 package common
 
 import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
 )
 
 func HealthRoutes(router *gin.Engine) {
-	healthGroup := router.Group("/ping")
+	healthGroup := router.Group("/ping", mw.Audit(consts.RouteHealth))
 	{
 		healthGroup.GET("", func(c *gin.Context) {
 			c.JSON(200, gin.H{"message": "Healthy!"})
 		})
+		healthGroup.GET("/ready", func(c *gin.Context) {
+			indices := clients.CheckIndexMappings(c)
+			ready := lo.EveryBy(indices, func(status clients.IndexStatus) bool { return status.Ready })
+
+			c.JSON(lo.Ternary(ready, http.StatusOK, http.StatusServiceUnavailable), gin.H{
+				"ready":   ready,
+				"indices": indices,
+			})
+		})
 	}
 }
@@ -1,14 +1,18 @@
 package common
 
 import (
+	"book_service/pkg/consts"
 	v1 "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
 
 	"github.com/gin-gonic/gin"
 )
 
 func StatisticRoutes(router *gin.Engine) {
-	statGroup := router.Group("/store")
+	statGroup := router.Group("/store", mw.Audit(consts.RouteRead), mw.RequireAuth(consts.RouteRead), mw.RateLimit(consts.RouteRead))
 	{
-		statGroup.GET("", v1.GetBooksStats)
+		statGroup.GET("", mw.Validation[req.StoreStats](), v1.GetBooksStats)
+		statGroup.GET("/timeseries", mw.Validation[req.TimeSeries](), v1.GetTimeSeries)
 	}
 }
@@ -12,4 +12,5 @@ func RegisterRoutes(router *gin.Engine) {
 	common.HealthRoutes(router)
 	common.ActionRoutes(router)
 	common.StatisticRoutes(router)
+	common.AdminRoutes(router)
 }
@@ -3,12 +3,19 @@ package utils
 import (
 	"book_service/pkg/consts"
 	"fmt"
-
-	"github.com/samber/lo"
 )
 
 type AggregationResult map[string]interface{}
 
+type Bucket struct {
+	Key          interface{}       `json:"key"`
+	KeyAsString  string            `json:"key_as_string,omitempty"`
+	DocCount     int               `json:"doc_count"`
+	From         *float64          `json:"from,omitempty"`
+	To           *float64          `json:"to,omitempty"`
+	Aggregations AggregationResult `json:"aggregations,omitempty"`
+}
+
 func ParseAggregations(aggregations map[string]interface{}, aggGroup map[string]consts.AggregationConfig) (AggregationResult, error) {
 	result := make(AggregationResult)
 
@@ -16,20 +23,133 @@ func ParseAggregations(aggregations map[string]interface{}, aggGroup map[string]
 		return result, nil
 	}
 
-	result = lo.MapValues(aggGroup, func(_ consts.AggregationConfig, aggName string) interface{} {
-		if aggData, ok := aggregations[aggName].(map[string]interface{}); ok {
-			if value, ok := aggData["value"].(float64); ok {
-				return int(value)
-			}
+	for aggName, aggConfig := range aggGroup {
+		rawData, requested := aggregations[aggName]
+		if !requested {
+			// Builders only request part of a group
+			continue
 		}
-		return nil
-	})
-
-	for aggName, value := range result {
-		if value == nil {
+		aggData, ok := rawData.(map[string]interface{})
+		if !ok {
 			return nil, fmt.Errorf("invalid format for aggregation %s", aggName)
 		}
+
+		value, err := parseAggregation(aggName, aggData, aggConfig)
+		if err != nil {
+			return nil, err
+		}
+		result[aggName] = value
 	}
 
 	return result, nil
 }
+
+func parseAggregation(aggName string, aggData map[string]interface{}, aggConfig consts.AggregationConfig) (interface{}, error) {
+	if consts.IsBucketAggregation(aggConfig.Type) {
+		return parseBuckets(aggName, aggData, aggConfig)
+	}
+
+	switch aggConfig.Type {
+	case consts.AggCardinality, consts.AggValueCount:
+		if value, ok := aggData["value"].(float64); ok {
+			return int(value), nil
+		}
+		return nil, fmt.Errorf("invalid format for aggregation %s", aggName)
+	case consts.AggPercentiles:
+		values, ok := aggData["values"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid format for aggregation %s", aggName)
+		}
+		return values, nil
+	default:
+		// Formatted metrics, e.g. min or max of a date, are reported as strings
+		if aggConfig.Format != "" {
+			if formatted, ok := aggData["value_as_string"].(string); ok {
+				return formatted, nil
+			}
+		}
+		// Metrics over an empty bucket come back as null
+		value, exists := aggData["value"]
+		if !exists {
+			return nil, fmt.Errorf("invalid format for aggregation %s", aggName)
+		}
+		if value == nil {
+			return nil, nil
+		}
+		if number, ok := value.(float64); ok {
+			return number, nil
+		}
+		return nil, fmt.Errorf("invalid format for aggregation %s", aggName)
+	}
+}
+
+func parseBuckets(aggName string, aggData map[string]interface{}, aggConfig consts.AggregationConfig) ([]Bucket, error) {
+	rawBuckets, ok := aggData["buckets"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid buckets for aggregation %s", aggName)
+	}
+
+	buckets := make([]Bucket, 0, len(rawBuckets))
+	for _, rawBucket := range rawBuckets {
+		bucketData, ok := rawBucket.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid bucket for aggregation %s", aggName)
+		}
+
+		docCount, _ := bucketData["doc_count"].(float64)
+		bucket := Bucket{
+			Key:      bucketData["key"],
+			DocCount: int(docCount),
+		}
+		if keyAsString, ok := bucketData["key_as_string"].(string); ok {
+			bucket.KeyAsString = keyAsString
+		}
+		if from, ok := bucketData["from"].(float64); ok {
+			bucket.From = &from
+		}
+		if to, ok := bucketData["to"].(float64); ok {
+			bucket.To = &to
+		}
+
+		if len(aggConfig.SubAggregations) > 0 {
+			subResult, err := ParseAggregations(bucketData, aggConfig.SubAggregations)
+			if err != nil {
+				return nil, fmt.Errorf("aggregation %s: %w", aggName, err)
+			}
+			bucket.Aggregations = subResult
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
+
+// ParseFacets unwraps the filter aggregation around each facet built by query.Builder.Facets.
+func ParseFacets(aggregations map[string]interface{}, names []string) (map[string][]Bucket, error) {
+	facets := make(map[string][]Bucket, len(names))
+
+	for _, name := range names {
+		facetConfig, ok := consts.FacetConfigs[name]
+		if !ok {
+			continue
+		}
+
+		wrapper, ok := aggregations[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid format for facet %s", name)
+		}
+		aggData, ok := wrapper[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid format for facet %s", name)
+		}
+
+		buckets, err := parseBuckets(name, aggData, facetConfig)
+		if err != nil {
+			return nil, err
+		}
+		facets[name] = buckets
+	}
+
+	return facets, nil
+}
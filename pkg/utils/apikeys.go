@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+type StaticAPIKey struct {
+	Subject string
+	Roles   []string
+	Tenant  string
+}
+
+// HashAPIKey is how API keys are looked up, so raw keys are never compared or stored.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseStaticAPIKeys reads "<key>:<subject>[:<role>|<role>[:<tenant>]]" entries separated by commas,
+// indexed by the hash of the key.
+func ParseStaticAPIKeys(config string) (map[string]StaticAPIKey, error) {
+	keys := make(map[string]StaticAPIKey)
+	for _, entry := range strings.Split(config, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid api key entry, expected <key>:<subject>[:<roles>[:<tenant>]]")
+		}
+
+		apiKey := StaticAPIKey{Subject: parts[1]}
+		if len(parts) >= 3 && parts[2] != "" {
+			apiKey.Roles = strings.Split(parts[2], "|")
+		}
+		if len(parts) == 4 {
+			if !IsValidTenant(parts[3]) {
+				return nil, fmt.Errorf("invalid tenant %q in api key entry of %s", parts[3], parts[1])
+			}
+			apiKey.Tenant = parts[3]
+		}
+		keys[HashAPIKey(parts[0])] = apiKey
+	}
+	return keys, nil
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"book_service/pkg/consts"
+	m "book_service/pkg/models/common"
+	"net/http"
+	"reflect"
+)
+
+// DiffFields summarises the fields that differ between two versions of a document.
+// A nil before describes a creation, a nil after a deletion.
+func DiffFields(before, after map[string]interface{}) map[string]m.FieldChange {
+	changes := make(map[string]m.FieldChange)
+	for field, to := range after {
+		from, existed := before[field]
+		if existed && reflect.DeepEqual(from, to) {
+			continue
+		}
+		changes[field] = m.FieldChange{From: from, To: to}
+	}
+	for field, from := range before {
+		if _, kept := after[field]; !kept {
+			changes[field] = m.FieldChange{From: from}
+		}
+	}
+	return changes
+}
+
+// ErrorCategory classifies the status of a handled request; successes have no category.
+func ErrorCategory(status int) string {
+	switch {
+	case status < http.StatusBadRequest:
+		return ""
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return consts.ErrorValidation
+	case status == http.StatusUnauthorized:
+		return consts.ErrorUnauthorized
+	case status == http.StatusForbidden:
+		return consts.ErrorForbidden
+	case status == http.StatusNotFound:
+		return consts.ErrorNotFound
+	case status == http.StatusConflict:
+		return consts.ErrorConflict
+	case status == http.StatusTooManyRequests:
+		return consts.ErrorRateLimited
+	case status < http.StatusInternalServerError:
+		return consts.ErrorClient
+	case status == http.StatusServiceUnavailable:
+		return consts.ErrorUnavailable
+	default:
+		return consts.ErrorServer
+	}
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"book_service/pkg/consts"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/samber/lo"
+)
+
+// BookKey is what the duplicate check compares of a book.
+type BookKey struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	AuthorName string `json:"author_name"`
+	ISBN       string `json:"isbn,omitempty"`
+}
+
+// DuplicateCluster is a group of books that are duplicates of one another, directly or through another member.
+type DuplicateCluster struct {
+	Books   []BookKey `json:"books"`
+	Reasons []string  `json:"reasons"`
+}
+
+// NormalizeText lowercases a title or author name and reduces punctuation and spacing to single spaces,
+// so "The Hobbit: Or There and Back Again" and "the hobbit or there  and back again" compare equal.
+func NormalizeText(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// NormalizeISBN drops the hyphens and spaces of an ISBN and uppercases its check character.
+func NormalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		return lo.Ternary(r == '-' || r == ' ', -1, r)
+	}, isbn))
+}
+
+// IsValidISBN checks the length, digits and check digit of an ISBN-10 or ISBN-13, hyphens allowed.
+func IsValidISBN(isbn string) bool {
+	isbn = NormalizeISBN(isbn)
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, r := range isbn {
+			digit := int(r - '0')
+			if r == 'X' && i == 9 {
+				digit = 10
+			} else if r < '0' || r > '9' {
+				return false
+			}
+			sum += (10 - i) * digit
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i, r := range isbn {
+			if r < '0' || r > '9' {
+				return false
+			}
+			sum += lo.Ternary(i%2 == 0, 1, 3) * int(r-'0')
+		}
+		return sum%10 == 0
+	}
+	return false
+}
+
+// TitleSimilarity is 1 minus the edit distance of the normalized titles relative to the longer one.
+func TitleSimilarity(a, b string) float64 {
+	ra, rb := []rune(NormalizeText(a)), []rune(NormalizeText(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// MatchDuplicate tells why b duplicates a: the same ISBN, the same normalized title and author,
+// or with threshold above 0, a title at least that similar by the same author. It returns "" otherwise.
+func MatchDuplicate(a, b BookKey, threshold float64) (string, float64) {
+	if a.ISBN != "" && NormalizeISBN(a.ISBN) == NormalizeISBN(b.ISBN) {
+		return consts.DuplicateByISBN, 1
+	}
+	if NormalizeText(a.AuthorName) != NormalizeText(b.AuthorName) {
+		return "", 0
+	}
+	if NormalizeText(a.Title) == NormalizeText(b.Title) {
+		return consts.DuplicateByTitle, 1
+	}
+	if threshold <= 0 {
+		return "", 0
+	}
+	if similarity := TitleSimilarity(a.Title, b.Title); similarity >= threshold {
+		return consts.DuplicateBySimilar, similarity
+	}
+	return "", 0
+}
+
+// ClusterDuplicates groups the books that duplicate one another, largest clusters first.
+// Only books by the same author are compared, apart from ISBNs which are matched across the catalogue.
+func ClusterDuplicates(books []BookKey, threshold float64) []DuplicateCluster {
+	parent := make([]int, len(books))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	reasons := make(map[int]map[string]bool)
+	union := func(i, j int, reason string) {
+		ri, rj := find(i), find(j)
+		if ri != rj {
+			parent[rj] = ri
+			for r := range reasons[rj] {
+				addReason(reasons, ri, r)
+			}
+			delete(reasons, rj)
+		}
+		addReason(reasons, ri, reason)
+	}
+
+	byISBN := make(map[string]int)
+	byAuthor := make(map[string][]int)
+	for i, book := range books {
+		if isbn := NormalizeISBN(book.ISBN); isbn != "" {
+			if first, ok := byISBN[isbn]; ok {
+				union(first, i, consts.DuplicateByISBN)
+			} else {
+				byISBN[isbn] = i
+			}
+		}
+		author := NormalizeText(book.AuthorName)
+		byAuthor[author] = append(byAuthor[author], i)
+	}
+
+	for _, indices := range byAuthor {
+		for x, i := range indices {
+			for _, j := range indices[x+1:] {
+				if reason, _ := MatchDuplicate(books[i], books[j], threshold); reason != "" {
+					union(i, j, reason)
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]BookKey)
+	for i, book := range books {
+		root := find(i)
+		members[root] = append(members[root], book)
+	}
+
+	clusters := make([]DuplicateCluster, 0)
+	for root, clusterBooks := range members {
+		if len(clusterBooks) < 2 {
+			continue
+		}
+		clusterReasons := lo.Keys(reasons[root])
+		sort.Strings(clusterReasons)
+		clusters = append(clusters, DuplicateCluster{Books: clusterBooks, Reasons: clusterReasons})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Books) != len(clusters[j].Books) {
+			return len(clusters[i].Books) > len(clusters[j].Books)
+		}
+		return clusters[i].Books[0].ID < clusters[j].Books[0].ID
+	})
+	return clusters
+}
+
+// MergeFields is the value of every consts.MergeFields field a merge leaves the survivor with: the chosen book's,
+// else the survivor's own, else that of the first duplicate having one. A chosen book without a value never
+// clears the survivor's.
+func MergeFields(books map[string]map[string]interface{}, survivorID string, duplicateIDs []string, choices map[string]string) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, field := range consts.MergeFields {
+		if value := books[choices[field]][field]; value != nil {
+			merged[field] = value
+			continue
+		}
+		for _, id := range append([]string{survivorID}, duplicateIDs...) {
+			if value, ok := books[id][field]; ok && value != nil {
+				merged[field] = value
+				break
+			}
+		}
+	}
+	return merged
+}
+
+func addReason(reasons map[int]map[string]bool, root int, reason string) {
+	if reasons[root] == nil {
+		reasons[root] = make(map[string]bool)
+	}
+	reasons[root][reason] = true
+}
+
+// editDistance is the Levenshtein distance of two rune slices.
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := lo.Ternary(a[i-1] == b[j-1], 0, 1)
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+)
+
+var idempotencyKeyPattern = regexp.MustCompile(`^[\x21-\x7E]{1,255}$`)
+
+// IsValidIdempotencyKey accepts 1 to 255 printable ASCII characters without spaces.
+func IsValidIdempotencyKey(key string) bool {
+	return idempotencyKeyPattern.MatchString(key)
+}
+
+// RequestFingerprint hashes what makes two requests the same: method, URI and body.
+// JSON bodies are compared by content, so formatting and key order do not matter.
+func RequestFingerprint(method, uri string, body []byte) string {
+	var content interface{}
+	if err := json.Unmarshal(body, &content); err == nil {
+		if canonical, err := json.Marshal(content); err == nil {
+			body = canonical
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + uri + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
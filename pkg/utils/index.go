@@ -0,0 +1,15 @@
+package utils
+
+import "strings"
+
+// FormatIndexName joins the non-empty parts of an index name with dashes.
+// Elasticsearch only accepts lowercase index names, so the result is lowercased.
+func FormatIndexName(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.ToLower(strings.Join(nonEmpty, "-"))
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"book_service/pkg/consts"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+type MappingDrift struct {
+	Field        string `json:"field"`
+	Kind         string `json:"kind"`
+	Expected     string `json:"expected,omitempty"`
+	Actual       string `json:"actual,omitempty"`
+	Incompatible bool   `json:"incompatible"`
+}
+
+// ParseMapping validates a mapping definition and returns its top level properties.
+func ParseMapping(mapping string) (map[string]interface{}, error) {
+	var definition map[string]interface{}
+	if err := json.Unmarshal([]byte(mapping), &definition); err != nil {
+		return nil, fmt.Errorf("invalid mapping json: %w", err)
+	}
+
+	mappings, ok := definition["mappings"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mapping has no 'mappings' section")
+	}
+
+	properties, ok := mappings["properties"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mapping has no 'properties' section")
+	}
+
+	return properties, nil
+}
+
+// CompareMappings reports every field of expected that is missing or typed differently in actual.
+// Fields present only in actual are ignored.
+func CompareMappings(expected, actual map[string]interface{}) []MappingDrift {
+	drifts := compareProperties("", expected, actual)
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Field < drifts[j].Field })
+	return drifts
+}
+
+func HasIncompatibleDrift(drifts []MappingDrift) bool {
+	for _, drift := range drifts {
+		if drift.Incompatible {
+			return true
+		}
+	}
+	return false
+}
+
+func compareProperties(prefix string, expected, actual map[string]interface{}) []MappingDrift {
+	var drifts []MappingDrift
+
+	for name, rawExpected := range expected {
+		field := prefix + name
+		expectedField, _ := rawExpected.(map[string]interface{})
+		actualField, ok := actual[name].(map[string]interface{})
+		if !ok {
+			drifts = append(drifts, MappingDrift{
+				Field:    field,
+				Kind:     consts.DriftMissingField,
+				Expected: fieldType(expectedField),
+			})
+			continue
+		}
+
+		if expectedType, actualType := fieldType(expectedField), fieldType(actualField); expectedType != actualType {
+			drifts = append(drifts, MappingDrift{
+				Field:        field,
+				Kind:         consts.DriftTypeMismatch,
+				Expected:     expectedType,
+				Actual:       actualType,
+				Incompatible: true,
+			})
+			continue
+		}
+
+		if expectedProps, ok := expectedField["properties"].(map[string]interface{}); ok {
+			actualProps, _ := actualField["properties"].(map[string]interface{})
+			drifts = append(drifts, compareProperties(field+".", expectedProps, actualProps)...)
+		}
+
+		if expectedSubs, ok := expectedField["fields"].(map[string]interface{}); ok {
+			actualSubs, _ := actualField["fields"].(map[string]interface{})
+			for _, drift := range compareProperties(field+".", expectedSubs, actualSubs) {
+				if drift.Kind == consts.DriftMissingField {
+					drift.Kind = consts.DriftMissingSubfield
+				}
+				// A missing multi-field cannot be backfilled for existing documents without a reindex
+				drift.Incompatible = true
+				drifts = append(drifts, drift)
+			}
+		}
+	}
+
+	return drifts
+}
+
+func fieldType(field map[string]interface{}) string {
+	if t, ok := field["type"].(string); ok {
+		return t
+	}
+	if _, ok := field["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// MappingVersion returns the version recorded in the "_meta" section of a mapping, or 0 when absent.
+func MappingVersion(mappings map[string]interface{}) int {
+	meta, _ := mappings["_meta"].(map[string]interface{})
+	if version, ok := meta["version"].(float64); ok {
+		return int(version)
+	}
+	return 0
+}
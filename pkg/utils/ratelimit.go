@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseRateLimit reads a "<requests>/<window>" limit such as "100/1m".
+func ParseRateLimit(limit string) (int, time.Duration, error) {
+	parts := strings.SplitN(limit, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid rate limit %q, expected <requests>/<window>", limit)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || requests <= 0 {
+		return 0, 0, fmt.Errorf("invalid request count in rate limit %q", limit)
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid window in rate limit %q", limit)
+	}
+
+	return requests, window, nil
+}
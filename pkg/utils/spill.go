@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrSpillFull = errors.New("spill buffer is full")
+
+const (
+	spillActiveFile   = "active.ndjson"
+	spillSealedPrefix = "sealed-"
+)
+
+// SpillBuffer is a bounded on-disk queue of newline delimited records. Appends go to an active
+// file, which Drain seals and replays oldest first, so records survive a restart of the process.
+type SpillBuffer struct {
+	mutex    sync.Mutex
+	dir      string
+	maxBytes int64
+	size     int64
+	sequence int64
+}
+
+func NewSpillBuffer(dir string, maxBytes int64) (*SpillBuffer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating spill directory: %w", err)
+	}
+
+	buffer := &SpillBuffer{dir: dir, maxBytes: maxBytes}
+	files, err := buffer.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			buffer.size += info.Size()
+		}
+	}
+	return buffer, nil
+}
+
+// Append writes all records or none of them, failing with ErrSpillFull when they do not fit.
+func (s *SpillBuffer) Append(records [][]byte) error {
+	var data bytes.Buffer
+	for _, record := range records {
+		if bytes.ContainsRune(record, '\n') {
+			return errors.New("spilled records cannot contain newlines")
+		}
+		data.Write(record)
+		data.WriteByte('\n')
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.size+int64(data.Len()) > s.maxBytes {
+		return ErrSpillFull
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.dir, spillActiveFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("opening spill file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data.Bytes()); err != nil {
+		return fmt.Errorf("writing spill file: %w", err)
+	}
+	s.size += int64(data.Len())
+	return nil
+}
+
+// Drain hands the spilled records to replay in batches, oldest first, deleting them once replayed.
+// When replay fails the remaining records are kept for the next Drain and its error is returned.
+func (s *SpillBuffer) Drain(batchSize int, replay func(records [][]byte) error) (int, error) {
+	if err := s.seal(); err != nil {
+		return 0, err
+	}
+
+	files, err := s.sealedFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, file := range files {
+		records, err := readRecords(file)
+		if err != nil {
+			return replayed, err
+		}
+
+		for len(records) > 0 {
+			batch := records[:min(batchSize, len(records))]
+			if err := replay(batch); err != nil {
+				return replayed, errors.Join(err, s.rewrite(file, records))
+			}
+			replayed += len(batch)
+			records = records[len(batch):]
+		}
+
+		if err := s.remove(file); err != nil {
+			return replayed, err
+		}
+	}
+	return replayed, nil
+}
+
+func (s *SpillBuffer) Size() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.size
+}
+
+// seal renames the active file so new appends start a fresh one while it is replayed.
+func (s *SpillBuffer) seal() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	active := filepath.Join(s.dir, spillActiveFile)
+	if _, err := os.Stat(active); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	s.sequence++
+	sealed := filepath.Join(s.dir, fmt.Sprintf("%s%020d-%06d.ndjson", spillSealedPrefix, time.Now().UnixNano(), s.sequence))
+	if err := os.Rename(active, sealed); err != nil {
+		return fmt.Errorf("sealing spill file: %w", err)
+	}
+	return nil
+}
+
+func (s *SpillBuffer) rewrite(file string, records [][]byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	before, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	var data bytes.Buffer
+	for _, record := range records {
+		data.Write(record)
+		data.WriteByte('\n')
+	}
+	if err := os.WriteFile(file, data.Bytes(), 0o640); err != nil {
+		return fmt.Errorf("rewriting spill file: %w", err)
+	}
+	s.size -= before.Size() - int64(data.Len())
+	return nil
+}
+
+func (s *SpillBuffer) remove(file string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		return fmt.Errorf("removing spill file: %w", err)
+	}
+	s.size -= info.Size()
+	return nil
+}
+
+func (s *SpillBuffer) files() ([]string, error) {
+	sealed, err := s.sealedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return append(sealed, filepath.Join(s.dir, spillActiveFile)), nil
+}
+
+func (s *SpillBuffer) sealedFiles() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing spill directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), spillSealedPrefix) {
+			files = append(files, filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	// Sealed names start with a fixed width timestamp, so they sort oldest first
+	sort.Strings(files)
+	return files, nil
+}
+
+func readRecords(file string) ([][]byte, error) {
+	handle, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("opening spill file: %w", err)
+	}
+	defer handle.Close()
+
+	var records [][]byte
+	scanner := bufio.NewScanner(handle)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		records = append(records, bytes.Clone(scanner.Bytes()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading spill file: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var streamIDPattern = regexp.MustCompile(`^[0-9]{1,20}-[0-9]{1,20}$`)
+
+// IsValidStreamID accepts complete Redis stream IDs ("<ms>-<seq>").
+func IsValidStreamID(id string) bool {
+	return streamIDPattern.MatchString(id)
+}
+
+// CompareStreamIDs orders two Redis stream IDs ("<ms>-<seq>"), returning -1, 0 or 1.
+// A missing sequence counts as 0 and unparsable parts as 0.
+func CompareStreamIDs(a, b string) int {
+	aMs, aSeq := splitStreamID(a)
+	bMs, bSeq := splitStreamID(b)
+	switch {
+	case aMs < bMs, aMs == bMs && aSeq < bSeq:
+		return -1
+	case aMs == bMs && aSeq == bSeq:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func splitStreamID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
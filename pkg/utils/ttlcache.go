@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTLCache is a small in-process cache whose entries expire after a fixed ttl.
+// Once maxEntries is reached, expired entries are dropped and, if that is not enough, the cache is reset.
+type TTLCache[V any] struct {
+	mutex      sync.Mutex
+	entries    map[string]ttlEntry[V]
+	ttl        time.Duration
+	maxEntries int
+}
+
+func NewTTLCache[V any](ttl time.Duration, maxEntries int) *TTLCache[V] {
+	return &TTLCache[V]{
+		entries:    make(map[string]ttlEntry[V]),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *TTLCache[V]) Get(key string, now time.Time) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *TTLCache[V]) Set(key string, value V, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		for existing, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, existing)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			c.entries = make(map[string]ttlEntry[V])
+		}
+	}
+	c.entries[key] = ttlEntry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *TTLCache[V]) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, key)
+}
+
+func (c *TTLCache[V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
@@ -4,6 +4,7 @@ import (
 	"book_service/pkg/consts"
 	m "book_service/pkg/models/common"
 	"fmt"
+	"regexp"
 
 	"github.com/samber/lo"
 
@@ -30,3 +31,29 @@ func GetValidatedPayload[T any](c *gin.Context) (T, error) {
 	typedVal, _ := val.(T)
 	return typedVal, nil
 }
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,47}$`)
+
+// IsValidTenant only accepts IDs that are safe to embed in index names and Redis keys,
+// so a tenant can never expand into a wildcard or a list of indices.
+func IsValidTenant(tenant string) bool {
+	return tenantPattern.MatchString(tenant)
+}
+
+func IsValidFacet(name string) bool {
+	_, ok := consts.FacetConfigs[name]
+	return ok
+}
+
+// IsValidFacetValue checks a selected bucket key; terms facets accept any value,
+// range facets only their declared keys.
+func IsValidFacetValue(name, value string) bool {
+	facetConfig, ok := consts.FacetConfigs[name]
+	if !ok {
+		return false
+	}
+	if facetConfig.Type == consts.AggTerms {
+		return value != ""
+	}
+	return lo.ContainsBy(facetConfig.Ranges, func(r consts.AggregationRange) bool { return r.Key == value })
+}
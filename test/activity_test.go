@@ -0,0 +1,95 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	v1 "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common"
+	"book_service/pkg/models/common/req"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivitySummarizer(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	summarizer := clients.NewActivitySummarizer(1)
+	summarizer.Add(clients.UserAction{User: "alice", Method: "GET", Route: "/api/v1/books/:id", BookID: "b1", Time: start})
+	summarizer.Add(clients.UserAction{User: "alice", Method: "PUT", Route: "/api/v1/books/:id", BookID: "b1", Time: start.Add(time.Hour)})
+	summarizer.Add(clients.UserAction{User: "alice", Method: "GET", Route: "/api/v1/books/:id", BookID: "b2", Time: start.Add(time.Minute)})
+	summarizer.Add(clients.UserAction{User: "bob", Method: "GET", Path: "/unknown", Time: start})
+
+	summaries := summarizer.Summaries()
+
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "alice", summaries[0].User)
+	assert.Equal(t, 3, summaries[0].Total)
+	assert.Equal(t, map[string]int{"GET /api/v1/books/:id": 2, "PUT /api/v1/books/:id": 1}, summaries[0].Actions)
+	assert.Equal(t, []clients.BookCount{{BookID: "b1", Count: 2}}, summaries[0].TopBooks)
+	assert.Equal(t, start.Add(time.Hour), summaries[0].LastSeen)
+
+	assert.Equal(t, "bob", summaries[1].User)
+	assert.Equal(t, map[string]int{"GET /unknown": 1}, summaries[1].Actions)
+	assert.Empty(t, summaries[1].TopBooks)
+}
+
+func TestActionWriter(t *testing.T) {
+	action := clients.UserAction{
+		ID:      "1700000000000-0",
+		Time:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		User:    "alice",
+		Method:  "PUT",
+		Path:    "/api/v1/books/b1",
+		Status:  http.StatusAccepted,
+		BookID:  "b1",
+		Changes: map[string]common.FieldChange{"title": {From: "Emma", To: "Persuasion"}},
+	}
+
+	var ndjson bytes.Buffer
+	writer := clients.NewActionWriter(&ndjson, consts.ExportNDJSON)
+	require.NoError(t, writer.Write(action))
+	require.NoError(t, writer.Write(action))
+	require.NoError(t, writer.Flush())
+
+	lines := bytes.Split(bytes.TrimSpace(ndjson.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var decoded clients.UserAction
+	require.NoError(t, json.Unmarshal(lines[0], &decoded))
+	assert.Equal(t, action.Changes, decoded.Changes)
+
+	var csvOutput bytes.Buffer
+	writer = clients.NewActionWriter(&csvOutput, consts.ExportCSV)
+	require.NoError(t, writer.Write(action))
+	require.NoError(t, writer.Flush())
+
+	rows, err := csv.NewReader(&csvOutput).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "id", rows[0][0])
+	assert.Equal(t, []string{"1700000000000-0", "2024-05-01T12:00:00Z", "alice"}, rows[1][:3])
+	assert.Contains(t, rows[1], `{"title":{"from":"Emma","to":"Persuasion"}}`)
+}
+
+func TestExportActivity_FailsCleanlyWithoutRedis(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "false")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/activity/export", mw.Validation[req.ActivityExport](), v1.ExportActivity)
+
+	rec := serveAuth(router, http.MethodGet, "/activity/export?format=csv", nil)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Disposition"))
+	assert.Contains(t, rec.Body.String(), `"message"`)
+
+	rec = serveAuth(router, http.MethodGet, "/activity/export?format=xml", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
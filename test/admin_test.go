@@ -0,0 +1,88 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdminRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/indices", mw.AdminAuth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestAdminAuth(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	router := newAdminRouter()
+
+	cases := map[string]int{
+		"":       http.StatusUnauthorized,
+		"wrong":  http.StatusUnauthorized,
+		"secret": http.StatusOK,
+	}
+	for token, expected := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/admin/indices", nil)
+		req.Header.Set(consts.AdminTokenHeader, token)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, expected, rec.Code, "token %q", token)
+	}
+}
+
+func TestAdminAuth_NoTokenConfigured(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "")
+	router := newAdminRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/indices", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestGetReindexTask_UnknownTask(t *testing.T) {
+	useFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"type":"resource_not_found_exception"},"status":404}`))
+	})
+
+	_, err := clients.GetReindexTask(context.Background(), "node:1")
+	assert.ErrorIs(t, err, clients.ErrTaskNotFound)
+	assert.NotErrorIs(t, err, clients.ErrIndexNotFound)
+}
+
+// useFakeElasticsearch points clients.EsClient at handler for the rest of the test.
+func useFakeElasticsearch(t *testing.T, handler http.HandlerFunc) {
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/" {
+			// Product check of the client
+			_, _ = w.Write([]byte(`{"version":{"number":"7.17.0","build_flavor":"default"},"tagline":"You Know, for Search"}`))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(es.Close)
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{es.URL}})
+	require.NoError(t, err)
+	previous := clients.EsClient
+	clients.EsClient = client
+	t.Cleanup(func() { clients.EsClient = previous })
+}
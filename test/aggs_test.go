@@ -0,0 +1,100 @@
+package test
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder_BucketAggregations(t *testing.T) {
+	result := query.NewQueryBuilder().BooksPerAuthor().BooksPerYear().Build()
+
+	expected := map[string]interface{}{
+		"books_per_author": map[string]interface{}{
+			"terms": map[string]interface{}{"field": "author_name.keyword", "size": 10},
+			"aggs": map[string]interface{}{
+				"avg_price": map[string]interface{}{"avg": map[string]interface{}{"field": "price"}},
+			},
+		},
+		"books_per_year": map[string]interface{}{
+			"date_histogram": map[string]interface{}{
+				"field":             "publish_date",
+				"calendar_interval": "year",
+				"format":            "yyyy",
+			},
+		},
+	}
+
+	assert.Equal(t, expected, result["aggs"])
+}
+
+func TestAggregationBody_Range(t *testing.T) {
+	body := query.AggregationBody(consts.AggregationConfig{
+		Field: "price",
+		Type:  consts.AggRange,
+		Ranges: []consts.AggregationRange{
+			{Key: "cheap", To: 10.0},
+			{Key: "mid", From: 10.0, To: 50.0},
+		},
+	})
+
+	expected := map[string]interface{}{
+		"range": map[string]interface{}{
+			"field": "price",
+			"ranges": []map[string]interface{}{
+				{"key": "cheap", "to": 10.0},
+				{"key": "mid", "from": 10.0, "to": 50.0},
+			},
+		},
+	}
+	assert.Equal(t, expected, body)
+}
+
+func TestParseAggregations_Buckets(t *testing.T) {
+	aggregations := map[string]interface{}{
+		"distinct_authors": map[string]interface{}{"value": float64(3)},
+		"books_per_author": map[string]interface{}{
+			"buckets": []interface{}{
+				map[string]interface{}{
+					"key":       "Jane Austen",
+					"doc_count": float64(2),
+					"avg_price": map[string]interface{}{"value": 12.5},
+				},
+			},
+		},
+		"books_per_year": map[string]interface{}{
+			"buckets": []interface{}{
+				map[string]interface{}{"key": float64(946684800000), "key_as_string": "2000", "doc_count": float64(4)},
+			},
+		},
+	}
+	group := map[string]consts.AggregationConfig{
+		"distinct_authors": consts.AggregationConfigs["BookStats"]["distinct_authors"],
+		"books_per_author": consts.AggregationConfigs["BookDistributions"]["books_per_author"],
+		"books_per_year":   consts.AggregationConfigs["BookDistributions"]["books_per_year"],
+	}
+
+	result, err := utils.ParseAggregations(aggregations, group)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result["distinct_authors"])
+	assert.Equal(t, []utils.Bucket{{
+		Key:          "Jane Austen",
+		DocCount:     2,
+		Aggregations: utils.AggregationResult{"avg_price": 12.5},
+	}}, result["books_per_author"])
+	assert.Equal(t, []utils.Bucket{{Key: float64(946684800000), KeyAsString: "2000", DocCount: 4}}, result["books_per_year"])
+}
+
+func TestParseAggregations_InvalidFormat(t *testing.T) {
+	aggregations := map[string]interface{}{
+		"distinct_authors": map[string]interface{}{"value": "three"},
+	}
+
+	_, err := utils.ParseAggregations(aggregations, consts.AggregationConfigs["BookStats"])
+
+	assert.Error(t, err)
+}
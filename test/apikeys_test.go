@@ -0,0 +1,101 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLCache(t *testing.T) {
+	cache := utils.NewTTLCache[string](time.Minute, 2)
+	now := time.Now()
+
+	cache.Set("a", "1", now)
+	value, ok := cache.Get("a", now.Add(30*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	_, ok = cache.Get("a", now.Add(time.Minute))
+	assert.False(t, ok, "entries expire after the ttl")
+
+	cache.Set("b", "2", now)
+	cache.Set("c", "3", now.Add(2*time.Minute))
+	assert.Equal(t, 1, cache.Len(), "expired entries are dropped once the cache is full")
+
+	cache.Delete("c")
+	_, ok = cache.Get("c", now.Add(2*time.Minute))
+	assert.False(t, ok)
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	unscoped := common.Principal{Subject: "importer"}
+	scoped := common.Principal{Subject: "importer", Scopes: []string{consts.RouteWrite}}
+
+	assert.True(t, unscoped.HasScope(consts.RouteActivity))
+	assert.True(t, scoped.HasScope(consts.RouteWrite))
+	assert.False(t, scoped.HasScope(consts.RouteSearch))
+	assert.False(t, scoped.HasScope(consts.APIKeyScopeAdmin))
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/apikeys", mw.Validation[req.CreateAPIKey](), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	cases := map[string]int{
+		`{"owner": "importer", "roles": ["editor"], "scopes": ["write"]}`: http.StatusCreated,
+		`{"owner": "importer", "expires_at": "2999-01-01T00:00:00Z"}`:     http.StatusCreated,
+		`{"roles": ["editor"]}`:                                       http.StatusBadRequest,
+		`{"owner": "importer", "roles": ["root"]}`:                    http.StatusBadRequest,
+		`{"owner": "importer", "scopes": ["everything"]}`:             http.StatusBadRequest,
+		`{"owner": "importer", "expires_at": "2000-01-01T00:00:00Z"}`: http.StatusBadRequest,
+	}
+	for body, expected := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/apikeys", bytes.NewBufferString(body)))
+		assert.Equal(t, expected, rec.Code, body)
+	}
+}
+
+func TestTouchAPIKey_OnlyWhileTheKeyIsCurrent(t *testing.T) {
+	server := useMiniredis(t)
+	ctx := context.Background()
+	usedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	apiKey, _, err := clients.CreateAPIKey(ctx, "importer", "", []string{consts.RoleEditor}, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, clients.TouchAPIKey(ctx, apiKey.ID, apiKey.Hash, usedAt))
+	stored, err := clients.GetAPIKey(ctx, apiKey.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.Equal(t, usedAt, *stored.LastUsedAt)
+
+	// A use of the rotated out key still in flight does not count for the new one
+	rotated, _, err := clients.RotateAPIKey(ctx, apiKey.ID)
+	require.NoError(t, err)
+	require.NoError(t, clients.TouchAPIKey(ctx, apiKey.ID, apiKey.Hash, usedAt))
+	stored, err = clients.GetAPIKey(ctx, apiKey.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.LastUsedAt)
+
+	// Nor does it bring a revoked key back
+	_, err = clients.RevokeAPIKey(ctx, apiKey.ID)
+	require.NoError(t, err)
+	require.NoError(t, clients.TouchAPIKey(ctx, apiKey.ID, rotated.Hash, usedAt))
+	assert.Empty(t, server.Keys())
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionFilter_Matches(t *testing.T) {
+	now := time.Now()
+	action := clients.UserAction{
+		Method: http.MethodPut,
+		Path:   "/api/v1/books/42",
+		Time:   now,
+		User:   "alice",
+		BookID: "42",
+	}
+
+	assert.True(t, clients.ActionFilter{}.Matches(action))
+	assert.True(t, clients.ActionFilter{User: "alice", Method: "put", Path: "/api/v1/books", BookID: "42"}.Matches(action))
+	assert.True(t, clients.ActionFilter{From: now.Add(-time.Minute), To: now.Add(time.Minute)}.Matches(action))
+
+	assert.False(t, clients.ActionFilter{User: "bob"}.Matches(action))
+	assert.False(t, clients.ActionFilter{Method: http.MethodDelete}.Matches(action))
+	assert.False(t, clients.ActionFilter{Path: "/store"}.Matches(action))
+	assert.False(t, clients.ActionFilter{BookID: "43"}.Matches(action))
+	assert.False(t, clients.ActionFilter{From: now.Add(time.Second)}.Matches(action))
+	assert.False(t, clients.ActionFilter{To: now.Add(-time.Second)}.Matches(action))
+}
+
+func TestDiffFields(t *testing.T) {
+	created := utils.DiffFields(nil, map[string]interface{}{"title": "Emma"})
+	assert.Equal(t, map[string]common.FieldChange{"title": {To: "Emma"}}, created)
+
+	updated := utils.DiffFields(
+		map[string]interface{}{"title": "Emma", "price": 10.0},
+		map[string]interface{}{"title": "Persuasion", "price": 10.0},
+	)
+	assert.Equal(t, map[string]common.FieldChange{"title": {From: "Emma", To: "Persuasion"}}, updated)
+
+	deleted := utils.DiffFields(map[string]interface{}{"title": "Emma"}, nil)
+	assert.Equal(t, map[string]common.FieldChange{"title": {From: "Emma"}}, deleted)
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(mw.RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, mw.GetRequestID(c))
+	})
+
+	rec := serveAuth(router, http.MethodGet, "/", map[string]string{consts.RequestIDHeader: "abc-123"})
+	assert.Equal(t, "abc-123", rec.Body.String())
+	assert.Equal(t, "abc-123", rec.Header().Get(consts.RequestIDHeader))
+
+	rec = serveAuth(router, http.MethodGet, "/", map[string]string{consts.RequestIDHeader: "not valid\n"})
+	assert.NotEqual(t, "not valid\n", rec.Body.String())
+	assert.Len(t, rec.Body.String(), 36)
+}
+
+func TestActivityValidation_Normalizes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/activity", mw.Validation[req.Activity](), func(c *gin.Context) {
+		activityReq, _ := utils.GetValidatedPayload[req.Activity](c)
+		c.JSON(http.StatusOK, activityReq)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/activity?method=put", nil))
+	assert.Contains(t, rec.Body.String(), `"Method":"PUT"`)
+	assert.Contains(t, rec.Body.String(), `"Limit":50`)
+}
+
+func TestErrorCategory(t *testing.T) {
+	cases := map[int]string{
+		http.StatusOK:                  "",
+		http.StatusAccepted:            "",
+		http.StatusBadRequest:          consts.ErrorValidation,
+		http.StatusUnauthorized:        consts.ErrorUnauthorized,
+		http.StatusForbidden:           consts.ErrorForbidden,
+		http.StatusNotFound:            consts.ErrorNotFound,
+		http.StatusConflict:            consts.ErrorConflict,
+		http.StatusTooManyRequests:     consts.ErrorRateLimited,
+		http.StatusMethodNotAllowed:    consts.ErrorClient,
+		http.StatusServiceUnavailable:  consts.ErrorUnavailable,
+		http.StatusInternalServerError: consts.ErrorServer,
+	}
+	for status, expected := range cases {
+		assert.Equal(t, expected, utils.ErrorCategory(status), status)
+	}
+}
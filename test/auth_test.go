@@ -0,0 +1,236 @@
+package test
+
+import (
+	"book_service/pkg/consts"
+	v1 "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeSegment(t *testing.T, value interface{}) string {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret string, claims map[string]interface{}) string {
+	unsigned := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	unsigned := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerifier_HS256(t *testing.T) {
+	verifier, err := utils.NewJWTVerifier([]byte("secret"), nil, "issuer", "books")
+	require.NoError(t, err)
+	now := time.Now()
+
+	claims, err := verifier.Verify(signHS256(t, "secret", map[string]interface{}{
+		"sub": "alice", "iss": "issuer", "aud": []string{"books"}, "exp": now.Add(time.Hour).Unix(), "roles": []string{"editor"},
+	}), now)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.Equal(t, []string{"editor"}, claims.Roles)
+
+	invalid := map[string]string{
+		"wrong secret": signHS256(t, "other", map[string]interface{}{"sub": "alice", "iss": "issuer", "aud": "books"}),
+		"expired":      signHS256(t, "secret", map[string]interface{}{"sub": "alice", "iss": "issuer", "aud": "books", "exp": now.Add(-time.Minute).Unix()}),
+		"wrong issuer": signHS256(t, "secret", map[string]interface{}{"sub": "alice", "iss": "other", "aud": "books"}),
+		"no subject":   signHS256(t, "secret", map[string]interface{}{"iss": "issuer", "aud": "books"}),
+		"alg none":     encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, map[string]string{"sub": "alice"}) + ".",
+		"malformed":    "not-a-token",
+	}
+	for name, token := range invalid {
+		_, err := verifier.Verify(token, now)
+		assert.ErrorIs(t, err, utils.ErrInvalidToken, name)
+	}
+}
+
+func TestJWTVerifier_RS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+
+	verifier, err := utils.NewJWTVerifier(nil, jwks, "", "")
+	require.NoError(t, err)
+
+	claims, err := verifier.Verify(signRS256(t, key, "k1", map[string]interface{}{"sub": "bob"}), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "bob", claims.Subject)
+
+	_, err = verifier.Verify(signRS256(t, key, "unknown", map[string]interface{}{"sub": "bob"}), time.Now())
+	assert.ErrorIs(t, err, utils.ErrInvalidToken)
+	// HS256 is not configured, so a token signed with anything must not pass
+	_, err = verifier.Verify(signHS256(t, "", map[string]interface{}{"sub": "bob"}), time.Now())
+	assert.ErrorIs(t, err, utils.ErrInvalidToken)
+}
+
+func newAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(mw.Authenticate())
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, mw.GetUserName(c))
+	}
+	router.GET("/search", mw.RequireAuth(consts.RouteSearch), handler)
+	router.POST("/books", mw.RequireAuth(consts.RouteWrite), handler)
+	return router
+}
+
+func serveAuth(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthenticate(t *testing.T) {
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, []byte(`{"keys": []}`), 0o600))
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("JWT_HS256_SECRET", "secret")
+	t.Setenv("JWT_JWKS_FILE", jwksFile)
+	t.Setenv("API_KEYS", "importer-key:importer:editor")
+	router := newAuthRouter()
+
+	// Anonymous callers may search but not write, and X-Username is no longer trusted
+	rec := serveAuth(router, http.MethodGet, "/search", map[string]string{"X-Username": "mallory"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, "mallory", rec.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, serveAuth(router, http.MethodPost, "/books", nil).Code)
+
+	token := signHS256(t, "secret", map[string]interface{}{"sub": "alice"})
+	rec = serveAuth(router, http.MethodPost, "/books", map[string]string{"Authorization": "Bearer " + token})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "alice", rec.Body.String())
+
+	rec = serveAuth(router, http.MethodPost, "/books", map[string]string{consts.APIKeyHeader: "importer-key"})
+	assert.Equal(t, "importer", rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, serveAuth(router, http.MethodGet, "/search", map[string]string{consts.APIKeyHeader: "bogus"}).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAuth(router, http.MethodGet, "/search", map[string]string{"Authorization": "Bearer bogus"}).Code)
+
+	t.Setenv("AUTH_ANONYMOUS_WRITE", "true")
+	assert.Equal(t, http.StatusOK, serveAuth(router, http.MethodPost, "/books", nil).Code)
+}
+
+func TestAuthenticate_Disabled(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "false")
+
+	rec := serveAuth(newAuthRouter(), http.MethodPost, "/books", map[string]string{"X-Username": "alice"})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "alice", rec.Body.String())
+}
+
+func TestParseStaticAPIKeys(t *testing.T) {
+	keys, err := utils.ParseStaticAPIKeys("k1:alice, k2:importer:editor|reader")
+	require.NoError(t, err)
+	assert.Equal(t, utils.StaticAPIKey{Subject: "alice"}, keys[utils.HashAPIKey("k1")])
+	assert.Equal(t, []string{"editor", "reader"}, keys[utils.HashAPIKey("k2")].Roles)
+
+	keys, err = utils.ParseStaticAPIKeys("k3:acme-importer:editor:acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", keys[utils.HashAPIKey("k3")].Tenant)
+
+	_, err = utils.ParseStaticAPIKeys("nosubject")
+	assert.Error(t, err)
+	_, err = utils.ParseStaticAPIKeys("k4:bob::Not*A*Tenant")
+	assert.Error(t, err)
+}
+
+func TestPrincipal_HasRole(t *testing.T) {
+	reader := common.Principal{Subject: "r"}
+	editor := common.Principal{Subject: "e", Roles: []string{consts.RoleEditor}}
+	admin := common.Principal{Subject: "a", Roles: []string{"unknown", consts.RoleAdmin}}
+
+	assert.True(t, reader.HasRole(consts.RoleReader))
+	assert.False(t, reader.HasRole(consts.RoleEditor))
+	assert.True(t, editor.HasRole(consts.RoleEditor))
+	assert.False(t, editor.HasRole(consts.RoleAdmin))
+	assert.True(t, admin.HasRole(consts.RoleEditor))
+	assert.True(t, admin.HasRole(consts.RoleAdmin))
+}
+
+func TestRoleAuthorization(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("JWT_HS256_SECRET", "secret")
+	t.Setenv("JWT_JWKS_FILE", "")
+	t.Setenv("API_KEYS", "")
+	t.Setenv("ADMIN_TOKEN", "")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(mw.Authenticate())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/books", mw.RequireRole(consts.RoleEditor), ok)
+	router.GET("/admin", mw.AdminAuth(), ok)
+	router.GET("/activity", mw.Validation[req.Activity](), v1.GetActivity)
+
+	bearer := func(roles ...string) map[string]string {
+		token := signHS256(t, "secret", map[string]interface{}{"sub": "alice", "roles": roles})
+		return map[string]string{"Authorization": "Bearer " + token}
+	}
+
+	cases := []struct {
+		method, path string
+		headers      map[string]string
+		expected     int
+	}{
+		{http.MethodPost, "/books", nil, http.StatusUnauthorized},
+		{http.MethodPost, "/books", bearer(), http.StatusForbidden},
+		{http.MethodPost, "/books", bearer(consts.RoleReader), http.StatusForbidden},
+		{http.MethodPost, "/books", bearer(consts.RoleEditor), http.StatusOK},
+		{http.MethodPost, "/books", bearer(consts.RoleAdmin), http.StatusOK},
+		{http.MethodGet, "/admin", nil, http.StatusUnauthorized},
+		{http.MethodGet, "/admin", bearer(consts.RoleEditor), http.StatusForbidden},
+		{http.MethodGet, "/admin", bearer(consts.RoleAdmin), http.StatusOK},
+		{http.MethodGet, "/activity?user=bob", bearer(consts.RoleEditor), http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		rec := serveAuth(router, tc.method, tc.path, tc.headers)
+		assert.Equal(t, tc.expected, rec.Code, "%s %s %v", tc.method, tc.path, tc.headers)
+	}
+
+	rec := serveAuth(router, http.MethodGet, "/admin", bearer(consts.RoleReader))
+	assert.JSONEq(t, `{"message": "Forbidden"}`, rec.Body.String())
+}
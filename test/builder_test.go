@@ -146,3 +146,38 @@ func TestQueryBuilder_ComplexCombination(t *testing.T) {
 
 	assert.ElementsMatch(t, expectedMust, actualMust, "The 'must' array does not match")
 }
+
+func TestQueryBuilder_ExcludeDeleted(t *testing.T) {
+	result := query.NewQueryBuilder().ID("12345").ExcludeDeleted().Build()
+
+	expected := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []map[string]interface{}{
+					{"term": map[string]interface{}{"_id": "12345"}},
+				},
+				"must_not": []map[string]interface{}{
+					{"exists": map[string]interface{}{"field": "deleted_at"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, result)
+}
+
+func TestQueryBuilder_OnlyDeleted(t *testing.T) {
+	result := query.NewQueryBuilder().OnlyDeleted().Build()
+
+	expected := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []map[string]interface{}{
+					{"exists": map[string]interface{}{"field": "deleted_at"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheGetOrLoad_BypassedWithoutRedis(t *testing.T) {
+	calls := 0
+	load := func() (map[string]interface{}, error) {
+		calls++
+		return map[string]interface{}{"title": "Emma"}, nil
+	}
+
+	value, hit, err := clients.CacheGetOrLoad(context.Background(), "cache::book:1", time.Minute, load)
+
+	assert.NoError(t, err)
+	assert.False(t, hit)
+	assert.Equal(t, "Emma", value["title"])
+	assert.Equal(t, 1, calls)
+}
+
+func TestCacheGetOrLoad_PropagatesLoadErrors(t *testing.T) {
+	loadErr := errors.New("boom")
+
+	_, _, err := clients.CacheGetOrLoad(context.Background(), "cache::book:2", time.Minute, func() (int, error) {
+		return 0, loadErr
+	})
+
+	assert.ErrorIs(t, err, loadErr)
+}
+
+func TestStatsCacheKey(t *testing.T) {
+	ctx := context.Background()
+	params := map[string]interface{}{"top": 5}
+
+	key := clients.StatsCacheKey(ctx, "acme", "store", params)
+
+	assert.Equal(t, key, clients.StatsCacheKey(ctx, "acme", "store", map[string]interface{}{"top": 5}))
+	assert.NotEqual(t, key, clients.StatsCacheKey(ctx, "acme", "store", map[string]interface{}{"top": 6}))
+	assert.NotEqual(t, key, clients.StatsCacheKey(ctx, "globex", "store", params))
+}
+
+func TestCacheKeys_NamespacedByIndexPrefixAndSuffix(t *testing.T) {
+	t.Setenv("INDEX_PREFIX", "")
+	t.Setenv("INDEX_SUFFIX", "")
+	assert.Equal(t, "cache:acme:book:1", clients.BookCacheKey("acme", "1"))
+
+	t.Setenv("INDEX_PREFIX", "Prod")
+	t.Setenv("INDEX_SUFFIX", "v2")
+	assert.Equal(t, "prod-v2:cache:acme:book:1", clients.BookCacheKey("acme", "1"))
+	assert.Contains(t, clients.StatsCacheKey(context.Background(), "acme", "store", nil), "prod-v2:cache:acme:stats:")
+	assert.True(t, strings.HasPrefix(clients.IdempotencyKey("acme", "bob", "k"), "prod-v2:idempotency:"))
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	v1 "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeFilter_Matches(t *testing.T) {
+	cheap := map[string]interface{}{"author_name": "Frank Herbert", "price": 15.0}
+	pricey := map[string]interface{}{"author_name": "Frank Herbert", "price": 80.0}
+
+	created := clients.BookEvent{Type: consts.EventBookCreated, After: cheap}
+	repriced := clients.BookEvent{Type: consts.EventBookUpdated, Before: cheap, After: pricey}
+	deleted := clients.BookEvent{Type: consts.EventBookDeleted, Before: pricey}
+
+	all := clients.ChangeFilter{}
+	assert.True(t, all.Matches(created))
+	assert.False(t, all.Matches(clients.BookEvent{Tenant: "acme", After: cheap}))
+
+	byAuthor := clients.ChangeFilter{AuthorName: "frank herbert"}
+	assert.True(t, byAuthor.Matches(created))
+	assert.False(t, clients.ChangeFilter{AuthorName: "Isaac Asimov"}.Matches(created))
+
+	underFifty := clients.ChangeFilter{MaxPrice: 50}
+	assert.True(t, underFifty.Matches(created))
+	assert.True(t, underFifty.Matches(repriced), "a book leaving the range is still reported")
+	assert.False(t, underFifty.Matches(deleted))
+	assert.True(t, clients.ChangeFilter{MinPrice: 50}.Matches(deleted))
+}
+
+func TestIsValidStreamID(t *testing.T) {
+	assert.True(t, utils.IsValidStreamID("1700000000000-0"))
+	assert.False(t, utils.IsValidStreamID("1700000000000"))
+	assert.False(t, utils.IsValidStreamID("$"))
+	assert.False(t, utils.IsValidStreamID("1-0\nevent: x"))
+}
+
+func TestStreamBookChanges_Requests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/changes", mw.Validation[req.BookChanges](), v1.StreamBookChanges)
+
+	cases := map[string]int{
+		"/changes?min_price=50&max_price=10":  http.StatusBadRequest,
+		"/changes?last_event_id=latest":       http.StatusBadRequest,
+		"/changes?author_name=Frank+Herbert":  http.StatusServiceUnavailable,
+		"/changes?last_event_id=1700000000-0": http.StatusServiceUnavailable,
+	}
+	for target, status := range cases {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, status, recorder.Code, target)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/changes", nil)
+	request.Header.Set(consts.LastEventIDHeader, "not-an-id")
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
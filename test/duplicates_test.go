@@ -0,0 +1,102 @@
+package test
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeText(t *testing.T) {
+	assert.Equal(t, "the hobbit or there and back again", utils.NormalizeText("The Hobbit: Or There  and Back Again!"))
+	assert.Equal(t, "j r r tolkien", utils.NormalizeText("J.R.R. Tolkien"))
+	assert.Equal(t, "", utils.NormalizeText(" -- "))
+}
+
+func TestIsValidISBN(t *testing.T) {
+	assert.True(t, utils.IsValidISBN("978-0-261-10221-7"))
+	assert.True(t, utils.IsValidISBN("0-8044-2957-x"))
+	assert.False(t, utils.IsValidISBN("978-0-261-10221-8"))
+	assert.False(t, utils.IsValidISBN("12345"))
+	assert.Equal(t, "080442957X", utils.NormalizeISBN("0-8044-2957-x"))
+
+	addBook := req.AddBook{ISBN: "978 0 261 10221 7"}
+	assert.NoError(t, addBook.Validate())
+	assert.Equal(t, "9780261102217", addBook.ISBN)
+	assert.Error(t, (&req.AddBook{ISBN: "not an isbn"}).Validate())
+}
+
+func TestMatchDuplicate(t *testing.T) {
+	hobbit := utils.BookKey{Title: "The Hobbit", AuthorName: "J.R.R. Tolkien"}
+
+	reason, _ := utils.MatchDuplicate(hobbit, utils.BookKey{Title: "the hobbit!", AuthorName: "j.r.r. tolkien"}, 0)
+	assert.Equal(t, consts.DuplicateByTitle, reason)
+
+	reason, _ = utils.MatchDuplicate(hobbit, utils.BookKey{Title: "The Hobbit", AuthorName: "Someone Else"}, 0)
+	assert.Empty(t, reason)
+
+	typo := utils.BookKey{Title: "The Hobit", AuthorName: "J.R.R. Tolkien"}
+	reason, _ = utils.MatchDuplicate(hobbit, typo, 0)
+	assert.Empty(t, reason, "similar titles only match with a threshold")
+	reason, similarity := utils.MatchDuplicate(hobbit, typo, 0.85)
+	assert.Equal(t, consts.DuplicateBySimilar, reason)
+	assert.InDelta(t, 0.9, similarity, 0.001)
+
+	reason, _ = utils.MatchDuplicate(
+		utils.BookKey{Title: "A", AuthorName: "X", ISBN: "9780261102217"},
+		utils.BookKey{Title: "B", AuthorName: "Y", ISBN: "978-0-261-10221-7"}, 0)
+	assert.Equal(t, consts.DuplicateByISBN, reason)
+}
+
+func TestClusterDuplicates(t *testing.T) {
+	books := []utils.BookKey{
+		{ID: "1", Title: "Dune", AuthorName: "Frank Herbert"},
+		{ID: "2", Title: "DUNE.", AuthorName: "frank herbert"},
+		{ID: "3", Title: "Dune Messiah", AuthorName: "Frank Herbert", ISBN: "9780261102217"},
+		{ID: "4", Title: "Hobbit", AuthorName: "Tolkien", ISBN: "9780261102217"},
+		{ID: "5", Title: "Emma", AuthorName: "Jane Austen"},
+	}
+
+	clusters := utils.ClusterDuplicates(books, 0)
+	if assert.Len(t, clusters, 2) {
+		assert.ElementsMatch(t, []string{"1", "2"}, clusterIDs(clusters[0]))
+		assert.Equal(t, []string{consts.DuplicateByTitle}, clusters[0].Reasons)
+		assert.ElementsMatch(t, []string{"3", "4"}, clusterIDs(clusters[1]))
+		assert.Equal(t, []string{consts.DuplicateByISBN}, clusters[1].Reasons)
+	}
+
+	// A low enough threshold joins Dune Messiah to Dune, and through its ISBN the Hobbit
+	clusters = utils.ClusterDuplicates(books, 0.3)
+	if assert.Len(t, clusters, 1) {
+		assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, clusterIDs(clusters[0]))
+		assert.Equal(t, []string{consts.DuplicateByISBN, consts.DuplicateBySimilar, consts.DuplicateByTitle}, clusters[0].Reasons)
+	}
+}
+
+func TestDuplicateCandidatesQuery(t *testing.T) {
+	q := query.DuplicateCandidates("Dune", "Frank Herbert", "9780261102217", false)
+	boolQuery := q["query"].(map[string]interface{})["bool"].(map[string]interface{})
+
+	should := boolQuery["should"].([]map[string]interface{})
+	assert.Len(t, should, 2)
+	assert.Equal(t, map[string]interface{}{"term": map[string]interface{}{"isbn": "9780261102217"}}, should[1])
+	assert.Equal(t, 1, boolQuery["minimum_should_match"])
+	assert.NotNil(t, boolQuery["must_not"])
+
+	q = query.DuplicateCandidates("Dune", "Frank Herbert", "", true)
+	should = q["query"].(map[string]interface{})["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+	assert.Len(t, should, 1)
+	must := should[0]["bool"].(map[string]interface{})["must"].([]map[string]interface{})
+	assert.Equal(t, "AUTO", must[1]["match"].(map[string]interface{})["title"].(map[string]interface{})["fuzziness"])
+}
+
+func clusterIDs(cluster utils.DuplicateCluster) []string {
+	ids := make([]string, 0, len(cluster.Books))
+	for _, book := range cluster.Books {
+		ids = append(ids, book.ID)
+	}
+	return ids
+}
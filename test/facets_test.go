@@ -0,0 +1,107 @@
+package test
+
+import (
+	"book_service/pkg/consts"
+	v1 "book_service/pkg/handlers/v1"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder_FacetsUsePostFilter(t *testing.T) {
+	result := query.NewQueryBuilder().
+		Facets([]string{consts.FacetAuthor, consts.FacetEbook}, map[string][]string{
+			consts.FacetAuthor: {"Jane Austen"},
+		}).
+		Build()
+
+	authorFilter := map[string]interface{}{
+		"terms": map[string]interface{}{"author_name.keyword": []string{"Jane Austen"}},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"bool": map[string]interface{}{"filter": []map[string]interface{}{authorFilter}},
+	}, result["post_filter"])
+
+	aggs := result["aggs"].(map[string]interface{})
+	// The author facet ignores its own selection, the others apply it
+	assert.Equal(t, map[string]interface{}{"match_all": map[string]interface{}{}},
+		aggs[consts.FacetAuthor].(map[string]interface{})["filter"])
+	assert.Equal(t, result["post_filter"], aggs[consts.FacetEbook].(map[string]interface{})["filter"])
+}
+
+func TestQueryBuilder_RangeFacetFilter(t *testing.T) {
+	result := query.NewQueryBuilder().
+		Facets(nil, map[string][]string{consts.FacetPrice: {"10-25", "100+"}}).
+		Build()
+
+	expected := map[string]interface{}{
+		"bool": map[string]interface{}{"filter": []map[string]interface{}{{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{"range": map[string]interface{}{"price": map[string]interface{}{"gte": 10.0, "lt": 25.0}}},
+					{"range": map[string]interface{}{"price": map[string]interface{}{"gte": 100.0}}},
+				},
+				"minimum_should_match": 1,
+			},
+		}}},
+	}
+	assert.Equal(t, expected, result["post_filter"])
+	assert.NotContains(t, result, "aggs")
+}
+
+func TestParseFacets(t *testing.T) {
+	aggregations := map[string]interface{}{
+		consts.FacetEbook: map[string]interface{}{
+			"doc_count": float64(5),
+			consts.FacetEbook: map[string]interface{}{
+				"buckets": []interface{}{
+					map[string]interface{}{"key": float64(1), "key_as_string": "true", "doc_count": float64(3)},
+				},
+			},
+		},
+	}
+
+	facets, err := utils.ParseFacets(aggregations, []string{consts.FacetEbook})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []utils.Bucket{{Key: float64(1), KeyAsString: "true", DocCount: 3}}, facets[consts.FacetEbook])
+}
+
+func TestSearchBooks_ValidateFacets(t *testing.T) {
+	search := req.SearchBooks{Facets: []string{"author,price", "decade"}}
+	assert.NoError(t, search.Validate())
+	assert.Equal(t, []string{"author", "price", "decade"}, search.Facets)
+
+	assert.Error(t, (&req.SearchBooks{Facets: []string{"publisher"}}).Validate())
+	assert.Error(t, (&req.SearchBooks{FacetFilters: map[string][]string{"price": {"5-6"}}}).Validate())
+	assert.NoError(t, (&req.SearchBooks{FacetFilters: map[string][]string{"decade": {"1990s"}}}).Validate())
+}
+
+func TestSearchBooks_SameShapeWithAndWithoutFacets(t *testing.T) {
+	t.Setenv("INDEX_PREFIX", "")
+	t.Setenv("INDEX_SUFFIX", "")
+	useFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":1},"hits":[{"_id":"1","_source":{"title":"Emma"}}]},
+			"aggregations":{"ebook":{"doc_count":1,"ebook":{"buckets":[{"key":1,"key_as_string":"true","doc_count":1}]}}}}`))
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/books/search", mw.Validation[req.SearchBooks](), v1.SearchBooks)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/search", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"hits": [{"title": "Emma"}]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/search?facets=ebook", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"hits": [{"title": "Emma"}], "facets": {"ebook": [{"key": 1, "key_as_string": "true", "doc_count": 1}]}}`, rec.Body.String())
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBookEvent_Restore(t *testing.T) {
+	snapshot := map[string]interface{}{"title": "Dune", "price": 15.0}
+	restore := clients.IndexRequest{ID: "1", RequestID: "req-1", Function: consts.DoRestoreIndex, Document: snapshot}
+
+	recreated := clients.NewBookEvent(restore, nil, 4)
+	assert.Equal(t, consts.EventBookCreated, recreated.Type)
+	assert.Equal(t, consts.OperationRestore, recreated.Operation)
+	assert.Equal(t, "req-1", recreated.RequestID)
+	assert.Equal(t, snapshot, recreated.After)
+
+	rolledBack := clients.NewBookEvent(restore, map[string]interface{}{"title": "Dune Messiah", "price": 15.0}, 5)
+	assert.Equal(t, consts.EventBookUpdated, rolledBack.Type)
+	assert.Equal(t, "Dune Messiah", rolledBack.Before["title"])
+}
+
+func TestRevisions_NumberedByVersion(t *testing.T) {
+	useMiniredis(t)
+	ctx := context.Background()
+	record := func(version int64, title string) {
+		_, err := clients.RecordRevision(ctx, clients.BookEvent{
+			Tenant: "acme", BookID: "1", Operation: consts.OperationUpdate, Version: version,
+			After: map[string]interface{}{"title": title},
+		})
+		require.NoError(t, err)
+	}
+
+	// Workers may record the revisions of concurrent writes out of order, and record one again
+	record(3, "Dune Messiah")
+	record(2, "Dune")
+	record(3, "Children of Dune")
+	record(4, "God Emperor of Dune")
+
+	page, err := clients.ListRevisions(ctx, "acme", "1", 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Revisions, 2)
+	assert.Equal(t, int64(4), page.Revisions[0].Revision)
+	assert.Equal(t, int64(3), page.Revisions[1].Revision)
+	assert.Equal(t, "Children of Dune", page.Revisions[1].Snapshot["title"])
+	assert.Equal(t, int64(3), page.NextBefore)
+
+	page, err = clients.ListRevisions(ctx, "acme", "1", page.NextBefore, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Revisions, 1)
+	assert.Equal(t, int64(2), page.Revisions[0].Revision)
+	assert.Zero(t, page.NextBefore)
+
+	revision, err := clients.GetRevision(ctx, "acme", "1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", revision.Snapshot["title"])
+	_, err = clients.GetRevision(ctx, "globex", "1", 2)
+	assert.ErrorIs(t, err, clients.ErrRevisionNotFound)
+}
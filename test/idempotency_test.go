@@ -0,0 +1,167 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useMiniredis points the clients at an in-memory Redis for the duration of the test.
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	previous := clients.UseRedisClient(client)
+	t.Cleanup(func() {
+		clients.UseRedisClient(previous)
+		_ = client.Close()
+	})
+	return server
+}
+
+// idempotentRouter serves POST /books through the idempotency middleware, answering with the body
+// and the given status.
+func idempotentRouter(status *int, calls *int, block chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/books", mw.Idempotency(), func(c *gin.Context) {
+		*calls++
+		if block != nil {
+			<-block
+		}
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(*status, string(body))
+	})
+	return router
+}
+
+func sendIdempotent(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(body))
+	request.Header.Set(consts.IdempotencyKeyHeader, key)
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRequestFingerprint(t *testing.T) {
+	base := utils.RequestFingerprint(http.MethodPost, "/api/v1/books/", []byte(`{"title": "Dune", "price": 15}`))
+
+	assert.Equal(t, base, utils.RequestFingerprint(http.MethodPost, "/api/v1/books/", []byte(`{"price":15,"title":"Dune"}`)))
+	assert.NotEqual(t, base, utils.RequestFingerprint(http.MethodPost, "/api/v1/books/", []byte(`{"title": "Dune", "price": 16}`)))
+	assert.NotEqual(t, base, utils.RequestFingerprint(http.MethodPut, "/api/v1/books/", []byte(`{"title": "Dune", "price": 15}`)))
+	assert.NotEqual(t, base, utils.RequestFingerprint(http.MethodPost, "/api/v1/books/?x=1", []byte(`{"title": "Dune", "price": 15}`)))
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	assert.True(t, utils.IsValidIdempotencyKey("import-2026-10-19-0001"))
+	assert.False(t, utils.IsValidIdempotencyKey("with space"))
+	assert.False(t, utils.IsValidIdempotencyKey(strings.Repeat("k", 256)))
+
+	key := clients.IdempotencyKey("acme", "alice", "k1")
+	assert.NotEqual(t, key, clients.IdempotencyKey("acme", "bob", "k1"))
+	assert.NotEqual(t, key, clients.IdempotencyKey("globex", "alice", "k1"))
+}
+
+func TestIdempotency_WithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/books", mw.Idempotency(), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusAccepted, string(body))
+	})
+
+	send := func(key string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(`{"title":"Dune"}`))
+		if key != "" {
+			request.Header.Set(consts.IdempotencyKeyHeader, key)
+		}
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusAccepted, send("").Code)
+	assert.Equal(t, http.StatusBadRequest, send("not valid").Code)
+
+	// Redis is down, the request still goes through with its body intact
+	recorder := send("k1")
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, `{"title":"Dune"}`, recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get(consts.IdempotentReplayedHeader))
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	useMiniredis(t)
+	status, calls := http.StatusAccepted, 0
+	router := idempotentRouter(&status, &calls, nil)
+
+	first := sendIdempotent(router, "k1", `{"title":"Dune"}`)
+	assert.Equal(t, http.StatusAccepted, first.Code)
+	assert.Empty(t, first.Header().Get(consts.IdempotentReplayedHeader))
+
+	// The same request in another key order is a repeat
+	replayed := sendIdempotent(router, "k1", `{ "title": "Dune" }`)
+	assert.Equal(t, http.StatusAccepted, replayed.Code)
+	assert.Equal(t, "true", replayed.Header().Get(consts.IdempotentReplayedHeader))
+	assert.Equal(t, `{"title":"Dune"}`, replayed.Body.String())
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_RejectsChangedBody(t *testing.T) {
+	useMiniredis(t)
+	status, calls := http.StatusAccepted, 0
+	router := idempotentRouter(&status, &calls, nil)
+
+	require.Equal(t, http.StatusAccepted, sendIdempotent(router, "k1", `{"title":"Dune"}`).Code)
+
+	recorder := sendIdempotent(router, "k1", `{"title":"Emma"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ConflictWhileInFlight(t *testing.T) {
+	server := useMiniredis(t)
+	status, calls := http.StatusAccepted, 0
+	block := make(chan struct{})
+	router := idempotentRouter(&status, &calls, block)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- sendIdempotent(router, "k1", `{"title":"Dune"}`) }()
+	require.Eventually(t, func() bool { return len(server.Keys()) == 1 }, time.Second, 5*time.Millisecond)
+
+	recorder := sendIdempotent(router, "k1", `{"title":"Dune"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	close(block)
+	assert.Equal(t, http.StatusAccepted, (<-done).Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ReleasesKeyAfterServerError(t *testing.T) {
+	server := useMiniredis(t)
+	status, calls := http.StatusServiceUnavailable, 0
+	router := idempotentRouter(&status, &calls, nil)
+
+	assert.Equal(t, http.StatusServiceUnavailable, sendIdempotent(router, "k1", `{"title":"Dune"}`).Code)
+	assert.Empty(t, server.Keys())
+
+	// The retry runs again instead of replaying the failure
+	status = http.StatusAccepted
+	recorder := sendIdempotent(router, "k1", `{"title":"Dune"}`)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Empty(t, recorder.Header().Get(consts.IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
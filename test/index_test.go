@@ -0,0 +1,28 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatIndexName(t *testing.T) {
+	assert.Equal(t, "books", utils.FormatIndexName("", "books", ""))
+	assert.Equal(t, "staging-books", utils.FormatIndexName("staging", "books", ""))
+	assert.Equal(t, "staging-books-acme", utils.FormatIndexName("Staging", "books", " ACME "))
+}
+
+func TestIndexName(t *testing.T) {
+	t.Setenv("BOOKS_INDEX", "")
+	t.Setenv("INDEX_PREFIX", "")
+	t.Setenv("INDEX_SUFFIX", "")
+	assert.Equal(t, "books", clients.IndexName(consts.BooksIndex))
+
+	t.Setenv("BOOKS_INDEX", "catalogue")
+	t.Setenv("INDEX_PREFIX", "prod")
+	t.Setenv("INDEX_SUFFIX", "v2")
+	assert.Equal(t, "prod-catalogue-v2", clients.IndexName(consts.BooksIndex))
+}
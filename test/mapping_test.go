@@ -0,0 +1,53 @@
+package test
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMapping_IndexMappingsAreValid(t *testing.T) {
+	for _, indexMapping := range consts.IndexMappings {
+		properties, err := utils.ParseMapping(indexMapping.Mapping)
+		assert.NoError(t, err, indexMapping.IndexName)
+		assert.NotEmpty(t, properties, indexMapping.IndexName)
+	}
+}
+
+func TestParseMapping_InvalidJSON(t *testing.T) {
+	_, err := utils.ParseMapping(`{"mappings": {"properties": {"title": {"type": "text"} // comment }}}`)
+	assert.Error(t, err)
+}
+
+func TestCompareMappings_NoDrift(t *testing.T) {
+	expected, err := utils.ParseMapping(consts.IndexMappings[0].Mapping)
+	assert.NoError(t, err)
+
+	assert.Empty(t, utils.CompareMappings(expected, expected))
+}
+
+func TestCompareMappings_Drift(t *testing.T) {
+	expected := map[string]interface{}{
+		"title": map[string]interface{}{"type": "text"},
+		"price": map[string]interface{}{"type": "float"},
+		"author_name": map[string]interface{}{
+			"type":   "text",
+			"fields": map[string]interface{}{"keyword": map[string]interface{}{"type": "keyword"}},
+		},
+	}
+	actual := map[string]interface{}{
+		"price":       map[string]interface{}{"type": "long"},
+		"author_name": map[string]interface{}{"type": "text"},
+	}
+
+	drifts := utils.CompareMappings(expected, actual)
+
+	assert.Equal(t, []utils.MappingDrift{
+		{Field: "author_name.keyword", Kind: consts.DriftMissingSubfield, Expected: "keyword", Incompatible: true},
+		{Field: "price", Kind: consts.DriftTypeMismatch, Expected: "float", Actual: "long", Incompatible: true},
+		{Field: "title", Kind: consts.DriftMissingField, Expected: "text"},
+	}, drifts)
+	assert.True(t, utils.HasIncompatibleDrift(drifts))
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/utils"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	survivorID  = "0b6f8a0e-8f5c-4a43-9a55-0e8f6f1d2c3b"
+	duplicateID = "6a1d3f52-2f57-4c0e-8f3e-1c9a8a7b6d54"
+	otherID     = "c3e4b1a2-9d8f-4e7a-b6c5-d4e3f2a1b0c9"
+)
+
+func TestMergeFields(t *testing.T) {
+	books := map[string]map[string]interface{}{
+		survivorID:  {"title": "Dune", "author_name": "Frank Herbert", "price": 15.0, "created_at": "2026-01-01T00:00:00Z"},
+		duplicateID: {"title": "Dune (Deluxe)", "author_name": "Frank Herbert", "price": 25.0, "isbn": "9780441172719"},
+		otherID:     {"title": "DUNE", "author_name": "Frank Herbert", "price": 9.0, "isbn": "9780340960196"},
+	}
+
+	merged := utils.MergeFields(books, survivorID, []string{duplicateID, otherID}, map[string]string{"price": otherID})
+	assert.Equal(t, map[string]interface{}{
+		"title":       "Dune",
+		"author_name": "Frank Herbert",
+		"price":       9.0,
+		// The survivor has none, so the first duplicate's is kept
+		"isbn": "9780441172719",
+	}, merged)
+
+	books[survivorID]["isbn"] = "9780441013593"
+	merged = utils.MergeFields(books, survivorID, []string{duplicateID}, map[string]string{"isbn": otherID, "title": duplicateID})
+	assert.Equal(t, "9780340960196", merged["isbn"])
+	assert.Equal(t, "Dune (Deluxe)", merged["title"])
+
+	// Choosing a book without the field keeps the survivor's value
+	delete(books[otherID], "isbn")
+	merged = utils.MergeFields(books, survivorID, []string{otherID}, map[string]string{"isbn": otherID})
+	assert.Equal(t, "9780441013593", merged["isbn"])
+}
+
+func TestMergeBooks_ValidateChoices(t *testing.T) {
+	books := map[string]map[string]interface{}{
+		survivorID:  {"title": "Dune", "isbn": "9780441013593"},
+		duplicateID: {"title": "Dune (Deluxe)", "isbn": nil},
+	}
+
+	assert.NoError(t, (&req.MergeBooks{Fields: map[string]string{"title": duplicateID}}).ValidateChoices(books))
+	assert.Error(t, (&req.MergeBooks{Fields: map[string]string{"isbn": duplicateID}}).ValidateChoices(books))
+	assert.Error(t, (&req.MergeBooks{Fields: map[string]string{"price": survivorID}}).ValidateChoices(books))
+}
+
+// runMerge applies the merge of duplicateID into survivorID through the index worker, with Elasticsearch
+// answering the update of the survivor with survivorStatus. It returns the books updated, in order.
+func runMerge(t *testing.T, survivorStatus int) (*miniredis.Miniredis, func() []string) {
+	server := useMiniredis(t)
+	t.Setenv("INDEX_PREFIX", "")
+	t.Setenv("INDEX_SUFFIX", "")
+	t.Setenv("CACHE_ENABLED", "false")
+
+	var mu sync.Mutex
+	var updated []string
+	useFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		// /books/_doc/<id> and /books/_doc/<id>/_update
+		id := strings.Split(r.URL.Path, "/")[3]
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"_id":"` + id + `","_version":1,"_seq_no":1,"_primary_term":1,"found":true,"_source":{"title":"Dune"}}`))
+			return
+		}
+		mu.Lock()
+		updated = append(updated, id)
+		mu.Unlock()
+		if id == survivorID && survivorStatus != http.StatusOK {
+			w.WriteHeader(survivorStatus)
+			_, _ = w.Write([]byte(`{"error":{"type":"es_rejected_execution_exception"},"status":429}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"_id":"` + id + `","_version":2,"result":"updated","get":{"_source":{"title":"Dune"}}}`))
+	})
+
+	deletedAt, deletedBy, mergedInto := "2026-10-19T00:00:00Z", "alice", survivorID
+	clients.InitElasticWorkerPool(1)
+	clients.EnqueueIndexTask(context.Background(), survivorID, common.BookMerge{
+		Survivor:     map[string]interface{}{"price": 25.0},
+		DuplicateIDs: []string{duplicateID},
+		Deletion:     common.BookDeletion{DeletedAt: &deletedAt, DeletedBy: &deletedBy, MergedInto: &mergedInto},
+	}, consts.DoMergeIndex)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), updated...)
+	}
+}
+
+func TestMerge_TrashesDuplicatesAfterTheSurvivor(t *testing.T) {
+	server, updated := runMerge(t, http.StatusOK)
+
+	require.Eventually(t, func() bool { return server.Exists("merged::" + duplicateID) }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{survivorID, duplicateID}, updated())
+	redirect, err := server.Get("merged::" + duplicateID)
+	require.NoError(t, err)
+	assert.Equal(t, survivorID, redirect)
+	members, err := server.ZMembers("trash:due")
+	require.NoError(t, err)
+	assert.Equal(t, []string{":" + duplicateID}, members)
+}
+
+func TestMerge_KeepsDuplicatesWhenTheSurvivorFails(t *testing.T) {
+	server, updated := runMerge(t, http.StatusTooManyRequests)
+
+	require.Eventually(t, func() bool { return len(updated()) > 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool { return len(updated()) > 1 }, 200*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, []string{survivorID}, updated())
+	assert.False(t, server.Exists("merged::"+duplicateID))
+	assert.False(t, server.Exists("trash:due"))
+}
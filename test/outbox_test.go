@@ -0,0 +1,208 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBookEvent(t *testing.T) {
+	before := map[string]interface{}{
+		"title":  "Dune",
+		"author": map[string]interface{}{"first_name": "Frank", "last_name": "Herbert"},
+	}
+
+	created := clients.NewBookEvent(clients.IndexRequest{
+		ID: "1", Tenant: "acme", Actor: "alice", Function: consts.DoCreateIndex,
+		Document: map[string]interface{}{"title": "Dune"},
+	}, nil, 1)
+	assert.Equal(t, consts.EventBookCreated, created.Type)
+	assert.Equal(t, consts.OperationCreate, created.Operation)
+	assert.Equal(t, "alice", created.Actor)
+	assert.Equal(t, int64(1), created.Version)
+	assert.Nil(t, created.Before)
+	assert.Equal(t, "Dune", created.After["title"])
+
+	updated := clients.NewBookEvent(clients.IndexRequest{
+		ID: "1", Function: consts.DoUpdateIndex,
+		Document: map[string]interface{}{"author": map[string]interface{}{"first_name": "F."}},
+	}, before, 2)
+	assert.Equal(t, consts.OperationUpdate, updated.Operation)
+	assert.Equal(t, before, updated.Before)
+	assert.Equal(t, "Dune", updated.After["title"])
+	assert.Equal(t, map[string]interface{}{"first_name": "F.", "last_name": "Herbert"}, updated.After["author"])
+	assert.Equal(t, "Frank", before["author"].(map[string]interface{})["first_name"])
+
+	deleted := clients.NewBookEvent(clients.IndexRequest{ID: "1", Function: consts.DoDeleteIndex}, before, 3)
+	assert.Equal(t, consts.EventBookDeleted, deleted.Type)
+	assert.Equal(t, before, deleted.Before)
+	assert.Nil(t, deleted.After)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buffer bytes.Buffer
+	sink := clients.NewWriterSink(consts.SinkStdout, &buffer)
+
+	events := []clients.BookEvent{{ID: "a", BookID: "1"}, {ID: "b", BookID: "2"}}
+	require.NoError(t, sink.Publish(context.Background(), events))
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var event clients.BookEvent
+	require.NoError(t, json.Unmarshal(lines[1], &event))
+	assert.Equal(t, "b", event.ID)
+}
+
+func TestFileSink_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.ndjson")
+
+	for _, id := range []string{"a", "b"} {
+		sink, err := clients.NewFileSink(path)
+		require.NoError(t, err)
+		assert.Equal(t, consts.SinkFile, sink.Name())
+		require.NoError(t, sink.Publish(context.Background(), []clients.BookEvent{{ID: id}}))
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event clients.BookEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []string{"a", "b"}, ids)
+}
+
+func TestCompareStreamIDs(t *testing.T) {
+	assert.Equal(t, 0, utils.CompareStreamIDs("1700000000000-1", "1700000000000-1"))
+	assert.Equal(t, -1, utils.CompareStreamIDs("1700000000000-1", "1700000000000-2"))
+	assert.Equal(t, -1, utils.CompareStreamIDs("999-9", "1000-0"))
+	assert.Equal(t, 1, utils.CompareStreamIDs("1000-0", "0-0"))
+	assert.Equal(t, 0, utils.CompareStreamIDs("1000", "1000-0"))
+}
+
+func TestAppendOutboxEvent_WithoutRedis(t *testing.T) {
+	assert.Error(t, clients.AppendOutboxEvent(context.Background(), clients.BookEvent{ID: "a"}, ""))
+}
+
+// outboxEvents decodes the events appended to the outbox stream.
+func outboxEvents(t *testing.T, server *miniredis.Miniredis) []clients.BookEvent {
+	entries, err := server.Stream("outbox:books")
+	require.NoError(t, err)
+	events := make([]clients.BookEvent, 0, len(entries))
+	for _, entry := range entries {
+		var event clients.BookEvent
+		require.NoError(t, json.Unmarshal([]byte(entry.Values[1]), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestIndexWorker_StagesEventsUntilAppended(t *testing.T) {
+	server := useMiniredis(t)
+	t.Setenv("INDEX_PREFIX", "")
+	t.Setenv("INDEX_SUFFIX", "")
+	t.Setenv("CACHE_ENABLED", "false")
+
+	var reads, updates atomic.Int32
+	var stagedDuringWrites []int
+	useFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			// Another write lands between the first read and the update
+			if reads.Add(1) == 1 {
+				_, _ = w.Write([]byte(`{"_id":"b1","_version":2,"_seq_no":5,"_primary_term":1,"found":true,"_source":{"title":"Dune","price":10}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"_id":"b1","_version":3,"_seq_no":6,"_primary_term":1,"found":true,"_source":{"title":"Dune","price":11}}`))
+		case http.MethodPost:
+			pending, _ := server.HKeys("outbox:pending")
+			stagedDuringWrites = append(stagedDuringWrites, len(pending))
+			if updates.Add(1) == 1 {
+				assert.Equal(t, "5", r.URL.Query().Get("if_seq_no"))
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"error":{"type":"version_conflict_engine_exception"},"status":409}`))
+				return
+			}
+			assert.Equal(t, "6", r.URL.Query().Get("if_seq_no"))
+			_, _ = w.Write([]byte(`{"_id":"b1","_version":4,"result":"updated","get":{"_source":{"title":"Dune","price":12}}}`))
+		}
+	})
+
+	clients.InitElasticWorkerPool(1)
+	clients.EnqueueIndexTask(context.Background(), "b1", map[string]interface{}{"price": 12}, consts.DoUpdateIndex)
+
+	require.Eventually(t, func() bool { return len(outboxEvents(t, server)) == 1 }, 2*time.Second, 10*time.Millisecond)
+	event := outboxEvents(t, server)[0]
+	assert.Equal(t, int64(4), event.Version)
+	assert.Equal(t, float64(11), event.Before["price"])
+	assert.Equal(t, float64(12), event.After["price"])
+
+	// Staged before each attempt, dropped with the conflict and once the event was appended
+	assert.Equal(t, []int{1, 1}, stagedDuringWrites)
+	assert.False(t, server.Exists("outbox:pending"))
+}
+
+func TestOutboxRecovery_ConfirmsPendingEvents(t *testing.T) {
+	server := useMiniredis(t)
+	t.Setenv("OUTBOX_SINKS", " ")
+	t.Setenv("OUTBOX_RECOVER_INTERVAL", "20ms")
+
+	useFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/books/_doc/applied":
+			_, _ = w.Write([]byte(`{"_id":"applied","_version":3,"_seq_no":6,"_primary_term":1,"found":true,"_source":{"title":"Dune","price":12}}`))
+		case "/books/_doc/lost":
+			_, _ = w.Write([]byte(`{"_id":"lost","_version":3,"_seq_no":6,"_primary_term":1,"found":true,"_source":{"title":"Emma","price":10}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"found":false}`))
+		}
+	})
+
+	stagedAt := time.Now().Add(-2 * consts.OutboxPendingGrace)
+	stage := func(field, bookID string) {
+		data, err := json.Marshal(map[string]interface{}{
+			"event":     clients.BookEvent{ID: field, Type: consts.EventBookUpdated, Operation: consts.OperationUpdate, BookID: bookID, Version: 3},
+			"index":     "books",
+			"written":   map[string]interface{}{"price": 12},
+			"staged_at": stagedAt,
+		})
+		require.NoError(t, err)
+		server.HSet("outbox:pending", field, string(data))
+	}
+	stage("applied", "applied")
+	stage("lost", "lost")
+	stage("missing", "missing")
+	server.HSet("outbox:pending", "garbled", "{")
+
+	clients.StartOutboxRelay()
+	t.Cleanup(clients.StopOutboxRelay)
+
+	require.Eventually(t, func() bool {
+		pending, _ := server.HKeys("outbox:pending")
+		return len(pending) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	events := outboxEvents(t, server)
+	require.Len(t, events, 1)
+	assert.Equal(t, "applied", events[0].BookID)
+	assert.Equal(t, float64(12), events[0].After["price"])
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	requests, window, err := utils.ParseRateLimit("100/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, requests)
+	assert.Equal(t, time.Minute, window)
+
+	for _, limit := range []string{"", "100", "0/1m", "ten/1m", "10/soon", "10/-1s"} {
+		_, _, err := utils.ParseRateLimit(limit)
+		assert.Error(t, err, limit)
+	}
+}
+
+func TestRateLimits_DefaultsAreValid(t *testing.T) {
+	for group, tiers := range consts.RateLimits {
+		for tier, limit := range tiers {
+			_, _, err := utils.ParseRateLimit(limit)
+			assert.NoError(t, err, "%s/%s", group, tier)
+		}
+	}
+}
+
+func TestRateLimit_FailsOpenWithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/search", mw.RateLimit(consts.RouteSearch), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimit_SlidingWindow(t *testing.T) {
+	useMiniredis(t)
+	t.Setenv("RATE_LIMIT_SEARCH_USER", "2/300ms")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/search", mw.RateLimit(consts.RouteSearch), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	search := func(user string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		req.Header.Set("X-Username", user)
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := search("alice")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, search("alice").Code)
+
+	limited := search("alice")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "0", limited.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+
+	// Every user has a window of their own
+	assert.Equal(t, http.StatusOK, search("bob").Code)
+
+	// Rejected requests do not count, so the window frees up once the accepted ones leave it
+	require.Eventually(t, func() bool { return search("alice").Code == http.StatusOK }, 2*time.Second, 100*time.Millisecond)
+}
+
+func TestGetUserTier(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, consts.TierAnonymous, mw.GetUserTier(c))
+
+	c.Request.Header.Set("X-Username", "alice")
+	assert.Equal(t, consts.TierUser, mw.GetUserTier(c))
+
+	c.Set(consts.TierKey, "partner")
+	assert.Equal(t, "partner", mw.GetUserTier(c))
+}
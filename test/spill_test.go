@@ -0,0 +1,76 @@
+package test
+
+import (
+	"book_service/pkg/utils"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillBuffer_AppendAndDrain(t *testing.T) {
+	spill, err := utils.NewSpillBuffer(t.TempDir(), 1024)
+	require.NoError(t, err)
+
+	require.NoError(t, spill.Append([][]byte{[]byte(`{"n":1}`), []byte(`{"n":2}`)}))
+	require.NoError(t, spill.Append([][]byte{[]byte(`{"n":3}`)}))
+	assert.Equal(t, int64(24), spill.Size())
+
+	var replayed []string
+	count, err := spill.Drain(2, func(records [][]byte) error {
+		for _, record := range records {
+			replayed = append(replayed, string(record))
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}, replayed)
+	assert.Equal(t, int64(0), spill.Size())
+}
+
+func TestSpillBuffer_Bounded(t *testing.T) {
+	spill, err := utils.NewSpillBuffer(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	require.NoError(t, spill.Append([][]byte{[]byte("12345")}))
+	assert.ErrorIs(t, spill.Append([][]byte{[]byte("12345")}), utils.ErrSpillFull)
+	assert.Error(t, spill.Append([][]byte{[]byte("a\nb")}))
+	assert.Equal(t, int64(6), spill.Size())
+}
+
+func TestSpillBuffer_KeepsRecordsWhenReplayFails(t *testing.T) {
+	dir := t.TempDir()
+	spill, err := utils.NewSpillBuffer(dir, 1024)
+	require.NoError(t, err)
+	require.NoError(t, spill.Append([][]byte{[]byte("a"), []byte("b"), []byte("c")}))
+
+	replayErr := errors.New("redis down")
+	batches := 0
+	count, err := spill.Drain(1, func(records [][]byte) error {
+		batches++
+		if batches == 2 {
+			return replayErr
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, replayErr)
+	assert.Equal(t, 1, count)
+
+	// A new buffer over the same directory, as after a restart, still holds the rest
+	reopened, err := utils.NewSpillBuffer(dir, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), reopened.Size())
+
+	var replayed []string
+	_, err = reopened.Drain(10, func(records [][]byte) error {
+		for _, record := range records {
+			replayed = append(replayed, string(record))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, replayed)
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder_StoreStats(t *testing.T) {
+	result := query.NewQueryBuilder().StoreStats(3).Build()
+
+	aggs := result["aggs"].(map[string]interface{})
+	assert.Len(t, aggs, 9)
+	assert.Equal(t, map[string]interface{}{
+		"terms": map[string]interface{}{"field": "author_name.keyword", "size": 3},
+	}, aggs["top_authors"])
+	assert.Equal(t, map[string]interface{}{
+		"min": map[string]interface{}{"field": "publish_date", "format": "yyyy-MM-dd"},
+	}, aggs["oldest_publish_date"])
+}
+
+func TestQueryBuilder_OpenPriceRange(t *testing.T) {
+	result := query.NewQueryBuilder().PriceRange(15, 0).Build()
+
+	expected := []map[string]interface{}{
+		{"range": map[string]interface{}{"price": map[string]interface{}{"gte": float64(15)}}},
+	}
+	assert.Equal(t, expected, result["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"])
+}
+
+func TestNewStoreStats(t *testing.T) {
+	stats := utils.AggregationResult{
+		"distinct_authors":    2,
+		"min_price":           5.0,
+		"avg_price":           7.5,
+		"max_price":           10.0,
+		"price_percentiles":   map[string]interface{}{"50.0": 7.5},
+		"oldest_publish_date": "1990-01-01",
+		"newest_publish_date": "2020-06-30",
+		"ebook_available": []utils.Bucket{
+			{Key: float64(0), KeyAsString: "false", DocCount: 3},
+			{Key: float64(1), KeyAsString: "true", DocCount: 1},
+		},
+		"top_authors": []utils.Bucket{{Key: "Jane Austen", DocCount: 3}},
+	}
+
+	storeStats := res.NewStoreStats(4, stats)
+
+	assert.Equal(t, 4, storeStats.TotalBooks)
+	assert.Equal(t, 2, storeStats.DistinctAuthors)
+	assert.Equal(t, 7.5, *storeStats.Price.Avg)
+	assert.Equal(t, 1, storeStats.EbookCount)
+	assert.Equal(t, 0.25, storeStats.EbookShare)
+	assert.Equal(t, "1990-01-01", *storeStats.OldestPublishDate)
+	assert.Equal(t, []res.AuthorCount{{Author: "Jane Austen", Books: 3}}, storeStats.TopAuthors)
+}
+
+func TestNewStoreStats_EmptyCatalogue(t *testing.T) {
+	storeStats := res.NewStoreStats(0, utils.AggregationResult{"avg_price": nil})
+
+	assert.Nil(t, storeStats.Price.Avg)
+	assert.Zero(t, storeStats.EbookShare)
+	assert.Empty(t, storeStats.TopAuthors)
+}
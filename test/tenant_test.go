@@ -0,0 +1,158 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTenantRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(mw.Authenticate(), mw.Tenant())
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, mw.GetTenant(c))
+	}
+	router.GET("/api/v1/books/search", handler)
+	router.GET("/ping", handler)
+	return router
+}
+
+func serveTenant(router *gin.Engine, path, tenant string, apiKey ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if tenant != "" {
+		req.Header.Set(consts.DefaultTenantHeader, tenant)
+	}
+	if len(apiKey) > 0 {
+		req.Header.Set(consts.APIKeyHeader, apiKey[0])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTenant_SharedCatalogue(t *testing.T) {
+	t.Setenv("TENANT_REQUIRED", "false")
+	t.Setenv("DEFAULT_TENANT", "")
+
+	rec := serveTenant(newTenantRouter(), "/api/v1/books/search", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "", rec.Body.String())
+}
+
+func TestTenant_Required(t *testing.T) {
+	t.Setenv("TENANT_REQUIRED", "true")
+	t.Setenv("DEFAULT_TENANT", "")
+	router := newTenantRouter()
+
+	assert.Equal(t, http.StatusBadRequest, serveTenant(router, "/api/v1/books/search", "").Code)
+	assert.Equal(t, http.StatusOK, serveTenant(router, "/ping", "").Code)
+}
+
+func TestTenant_RejectsUnsafeIDs(t *testing.T) {
+	router := newTenantRouter()
+
+	for _, tenant := range []string{"*", "acme,globex", "acme-eu", "../books", "_all"} {
+		assert.Equal(t, http.StatusBadRequest, serveTenant(router, "/api/v1/books/search", tenant).Code, tenant)
+	}
+}
+
+// useProvisionedTenants fakes an Elasticsearch holding the books index of the given tenants only.
+func useProvisionedTenants(t *testing.T, tenants ...string) {
+	t.Setenv("BOOKS_INDEX", "")
+	t.Setenv("INDEX_PREFIX", "")
+	t.Setenv("INDEX_SUFFIX", "")
+	useFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, tenant := range tenants {
+			if r.URL.Path == "/"+clients.TenantIndexName(consts.BooksIndex, tenant) {
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+func TestTenant_UnknownTenant(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "false")
+	useProvisionedTenants(t, "acme")
+	router := newTenantRouter()
+
+	rec := serveTenant(router, "/api/v1/books/search", "acme")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "acme", rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, serveTenant(router, "/api/v1/books/search", "ghost").Code)
+}
+
+func TestTenant_BoundToCaller(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "acme-key:acme-importer:editor:acme,shared-key:bob,admin-key:root:admin")
+	t.Setenv("DEFAULT_TENANT", "")
+	useProvisionedTenants(t, "acme", "globex")
+	router := newTenantRouter()
+	path := "/api/v1/books/search"
+
+	// A key bound to a tenant is scoped to it, and cannot name another one
+	rec := serveTenant(router, path, "", "acme-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "acme", rec.Body.String())
+	assert.Equal(t, http.StatusOK, serveTenant(router, path, "acme", "acme-key").Code)
+	assert.Equal(t, http.StatusForbidden, serveTenant(router, path, "globex", "acme-key").Code)
+
+	// Callers without a tenant stay on the shared catalogue, unless they are admins
+	assert.Equal(t, http.StatusForbidden, serveTenant(router, path, "globex").Code)
+	assert.Equal(t, http.StatusForbidden, serveTenant(router, path, "globex", "shared-key").Code)
+	rec = serveTenant(router, path, "globex", "admin-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "globex", rec.Body.String())
+}
+
+func TestTenant_JWTClaim(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("JWT_HS256_SECRET", "secret")
+	t.Setenv("DEFAULT_TENANT", "")
+	useProvisionedTenants(t, "acme")
+	router := newTenantRouter()
+	serve := func(tenant, claim string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/books/search", nil)
+		req.Header.Set(consts.DefaultTenantHeader, tenant)
+		req.Header.Set("Authorization", "Bearer "+signHS256(t, "secret", map[string]interface{}{"sub": "alice", "tenant": claim}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The claim is lowercased like the header
+	rec := serve("acme", "ACME")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "acme", rec.Body.String())
+
+	// Claims that would expand into several indices invalidate the token
+	for _, claim := range []string{"*", "acme,globex", "books-*"} {
+		assert.Equal(t, http.StatusUnauthorized, serve("acme", claim).Code, claim)
+	}
+}
+
+func TestIsValidTenant(t *testing.T) {
+	assert.True(t, utils.IsValidTenant("acme"))
+	assert.True(t, utils.IsValidTenant("team_42"))
+	assert.False(t, utils.IsValidTenant(""))
+	assert.False(t, utils.IsValidTenant("Acme"))
+	assert.False(t, utils.IsValidTenant("books*"))
+}
+
+func TestTenantIndexName(t *testing.T) {
+	t.Setenv("BOOKS_INDEX", "")
+	t.Setenv("INDEX_PREFIX", "prod")
+	t.Setenv("INDEX_SUFFIX", "")
+
+	assert.Equal(t, "prod-books", clients.TenantIndexName(consts.BooksIndex, ""))
+	assert.Equal(t, "prod-books-acme", clients.TenantIndexName(consts.BooksIndex, "acme"))
+}
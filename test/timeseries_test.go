@@ -0,0 +1,78 @@
+package test
+
+import (
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common/req"
+	"book_service/pkg/models/common/res"
+	"book_service/pkg/query"
+	"book_service/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder_TimeSeries(t *testing.T) {
+	result := query.NewQueryBuilder().
+		DateRange("created_at", "2024-01-01", "", "Europe/Paris").
+		TimeSeries("created_at", "week", "Europe/Paris", []string{consts.MetricCount, consts.MetricAvgPrice}).
+		Build()
+
+	assert.Equal(t, map[string]interface{}{
+		"date_histogram": map[string]interface{}{
+			"field":             "created_at",
+			"calendar_interval": "week",
+			"time_zone":         "Europe/Paris",
+			"min_doc_count":     0,
+		},
+		"aggs": map[string]interface{}{
+			"avg_price": map[string]interface{}{"avg": map[string]interface{}{"field": "price"}},
+		},
+	}, result["aggs"].(map[string]interface{})[query.TimeSeriesAggregation])
+
+	assert.Equal(t, []map[string]interface{}{
+		{"range": map[string]interface{}{"created_at": map[string]interface{}{"gte": "2024-01-01", "time_zone": "Europe/Paris"}}},
+	}, result["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"])
+}
+
+func TestTimeSeries_Validate(t *testing.T) {
+	series := req.TimeSeries{}
+	assert.NoError(t, series.Validate())
+	assert.Equal(t, req.TimeSeries{
+		Field:    "publish_date",
+		Interval: "month",
+		TimeZone: "UTC",
+		Metrics:  []string{consts.MetricCount},
+	}, series)
+
+	assert.NoError(t, (&req.TimeSeries{TimeZone: "+02:00", Metrics: []string{"count,ebook_count"}}).Validate())
+	assert.Error(t, (&req.TimeSeries{TimeZone: "Mars/Olympus"}).Validate())
+	assert.Error(t, (&req.TimeSeries{Metrics: []string{"max_price"}}).Validate())
+	assert.Error(t, (&req.TimeSeries{From: "2024-02-01", To: "2024-01-01"}).Validate())
+}
+
+func TestTimeSeries_ValidateWindow(t *testing.T) {
+	assert.NoError(t, (&req.TimeSeries{Interval: "year"}).Validate())
+	assert.NoError(t, (&req.TimeSeries{Interval: "day", From: "2024-01-01", To: "2024-12-31"}).Validate())
+	assert.NoError(t, (&req.TimeSeries{Interval: "week", From: "2015-01-01", To: "2024-12-31"}).Validate())
+
+	assert.Error(t, (&req.TimeSeries{Interval: "day"}).Validate())
+	assert.Error(t, (&req.TimeSeries{Interval: "day", From: "2024-01-01"}).Validate())
+	assert.Error(t, (&req.TimeSeries{Interval: "day", From: "2020-01-01", To: "2024-12-31"}).Validate())
+	assert.Error(t, (&req.TimeSeries{Interval: "week", From: "1990-01-01", To: "2024-12-31"}).Validate())
+}
+
+func TestNewTimeSeriesPoints(t *testing.T) {
+	buckets := []utils.Bucket{{
+		KeyAsString:  "2024-01-01",
+		DocCount:     4,
+		Aggregations: utils.AggregationResult{"avg_price": 12.0, "ebook_count": 3.0},
+	}}
+
+	points := res.NewTimeSeriesPoints(buckets, []string{consts.MetricAvgPrice, consts.MetricEbookCount})
+
+	assert.Len(t, points, 1)
+	assert.Equal(t, "2024-01-01", points[0].Date)
+	assert.Nil(t, points[0].Count)
+	assert.Equal(t, 12.0, *points[0].AvgPrice)
+	assert.Equal(t, 3, *points[0].EbookCount)
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/models/common"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookDeletion_ClearsMarkers(t *testing.T) {
+	data, err := json.Marshal(common.BookDeletion{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"deleted_at": null, "deleted_by": null, "merged_into": null}`, string(data))
+}
+
+func TestNewBookEvent_Undelete(t *testing.T) {
+	trashed := map[string]interface{}{"title": "Dune", "deleted_at": "2026-01-01T00:00:00Z", "deleted_by": "alice"}
+
+	event := clients.NewBookEvent(clients.IndexRequest{ID: "1", Function: consts.DoUndeleteIndex, Document: common.BookDeletion{}}, trashed, 7)
+	assert.Equal(t, consts.EventBookCreated, event.Type)
+	assert.Equal(t, consts.OperationUndelete, event.Operation)
+	assert.Equal(t, trashed, event.Before)
+	assert.Equal(t, map[string]interface{}{"title": "Dune"}, event.After)
+}
+
+// runTrashPurge runs the purger over a trash holding an expired book b1 and a recent one b2,
+// with Elasticsearch answering the read of b1 with its source and its delete with deleteStatus.
+func runTrashPurge(t *testing.T, source string, deleteStatus int) (server *miniredis.Miniredis, deletes *atomic.Int32) {
+	server = useMiniredis(t)
+	t.Setenv("INDEX_PREFIX", "")
+	t.Setenv("INDEX_SUFFIX", "")
+	t.Setenv("TRASH_RETENTION", "1h")
+	t.Setenv("TRASH_PURGE_INTERVAL", "20ms")
+	// Keeps the delayed cache invalidation from outliving the test
+	t.Setenv("CACHE_ENABLED", "false")
+
+	deletes = &atomic.Int32{}
+	useFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/books-acme/_doc/b1", r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"_id":"b1","_version":2,"_seq_no":5,"_primary_term":1,"found":true,"_source":` + source + `}`))
+		case http.MethodDelete:
+			assert.Equal(t, "5", r.URL.Query().Get("if_seq_no"))
+			assert.Equal(t, "1", r.URL.Query().Get("if_primary_term"))
+			deletes.Add(1)
+			w.WriteHeader(deleteStatus)
+			_, _ = w.Write([]byte(`{"_id":"b1","_version":3,"result":"deleted"}`))
+		}
+	})
+
+	expired := float64(time.Now().Add(-2 * time.Hour).UnixMilli())
+	_, err := server.ZAdd("trash:due", expired, "acme:b1")
+	require.NoError(t, err)
+	_, err = server.ZAdd("trash:due", float64(time.Now().UnixMilli()), "acme:b2")
+	require.NoError(t, err)
+	require.NoError(t, server.Set("revisions:acme:book:b1", "kept until the purge"))
+
+	clients.InitElasticWorkerPool(1)
+	clients.StartTrashPurger()
+	t.Cleanup(clients.StopTrashPurger)
+	return server, deletes
+}
+
+func TestTrashPurge_DeletesExpiredBooks(t *testing.T) {
+	server, deletes := runTrashPurge(t, `{"title":"Dune","deleted_at":"2026-01-01T00:00:00Z"}`, http.StatusOK)
+
+	require.Eventually(t, func() bool { return !server.Exists("revisions:acme:book:b1") }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), deletes.Load())
+	members, err := server.ZMembers("trash:due")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme:b2"}, members)
+}
+
+func TestTrashPurge_ReturnsFailedPurgesToTheTrash(t *testing.T) {
+	server, deletes := runTrashPurge(t, `{"title":"Dune","deleted_at":"2026-01-01T00:00:00Z"}`, http.StatusInternalServerError)
+
+	require.Eventually(t, func() bool { return deletes.Load() > 0 }, 2*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		score, err := server.ZScore("trash:due", "acme:b1")
+		return err == nil && score < float64(time.Now().Add(-time.Hour).UnixMilli())
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, server.Exists("revisions:acme:book:b1"))
+}
+
+func TestTrashPurge_KeepsBooksRestoredMeanwhile(t *testing.T) {
+	server, deletes := runTrashPurge(t, `{"title":"Dune"}`, http.StatusOK)
+
+	require.Eventually(t, func() bool {
+		members, _ := server.ZMembers("trash:due")
+		return len(members) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool { return deletes.Load() > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+	assert.True(t, server.Exists("revisions:acme:book:b1"))
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	mw "book_service/pkg/middlewares"
+	"book_service/pkg/models/common/req"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestValidation checks the bounds of the request models; the handlers behind them
+// are tested in the files of their feature.
+func TestRequestValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/trash", mw.Validation[req.Trash](), ok)
+	router.DELETE("/trash/:id", mw.Validation[req.TrashedBook](), ok)
+	router.GET("/books/:id/history", mw.Validation[req.BookHistory](), ok)
+	router.GET("/books/:id/history/diff", mw.Validation[req.BookRevisionDiff](), ok)
+	router.POST("/books/:id/restore", mw.Validation[req.RestoreBook](), ok)
+	router.POST("/books/_merge", mw.Validation[req.MergeBooks](), ok)
+	router.POST("/webhooks", mw.Validation[req.CreateWebhook](), ok)
+	router.GET("/activity", mw.Validation[req.Activity](), ok)
+
+	const hook = `"url": "https://pricing.example.com/hooks"`
+	merge := `"survivor_id": "` + survivorID + `", "duplicate_ids": `
+	cases := []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodGet, "/trash", "", http.StatusOK},
+		{http.MethodGet, "/trash?from=20&size=20", "", http.StatusOK},
+		{http.MethodGet, "/trash?size=1000", "", http.StatusBadRequest},
+		{http.MethodDelete, "/trash/" + survivorID, "", http.StatusOK},
+		{http.MethodDelete, "/trash/42", "", http.StatusBadRequest},
+
+		{http.MethodGet, "/books/" + survivorID + "/history?before=10&limit=5", "", http.StatusOK},
+		{http.MethodGet, "/books/" + survivorID + "/history?limit=500", "", http.StatusBadRequest},
+		{http.MethodGet, "/books/not-a-uuid/history", "", http.StatusBadRequest},
+		{http.MethodGet, "/books/" + survivorID + "/history/diff?from=1&to=3", "", http.StatusOK},
+		{http.MethodGet, "/books/" + survivorID + "/history/diff?from=2&to=2", "", http.StatusBadRequest},
+		{http.MethodGet, "/books/" + survivorID + "/history/diff?from=1", "", http.StatusBadRequest},
+		{http.MethodPost, "/books/" + survivorID + "/restore?revision=3", "", http.StatusOK},
+		{http.MethodPost, "/books/" + survivorID + "/restore", "", http.StatusBadRequest},
+		{http.MethodPost, "/books/" + survivorID + "/restore?revision=-1", "", http.StatusBadRequest},
+
+		{http.MethodPost, "/books/_merge", `{` + merge + `["` + duplicateID + `"], "fields": {"price": "` + duplicateID + `"}}`, http.StatusOK},
+		{http.MethodPost, "/books/_merge", `{` + merge + `[]}`, http.StatusBadRequest},
+		{http.MethodPost, "/books/_merge", `{` + merge + `["` + survivorID + `"]}`, http.StatusBadRequest},
+		{http.MethodPost, "/books/_merge", `{` + merge + `["42"]}`, http.StatusBadRequest},
+		{http.MethodPost, "/books/_merge", `{` + merge + `["` + duplicateID + `"], "fields": {"created_at": "` + duplicateID + `"}}`, http.StatusBadRequest},
+		{http.MethodPost, "/books/_merge", `{` + merge + `["` + duplicateID + `"], "fields": {"price": "` + otherID + `"}}`, http.StatusBadRequest},
+
+		{http.MethodPost, "/webhooks", `{` + hook + `, "events": ["book.updated"], "secret": "0123456789abcdef"}`, http.StatusOK},
+		{http.MethodPost, "/webhooks", `{"url": "ftp://pricing.example.com/hooks"}`, http.StatusBadRequest},
+		{http.MethodPost, "/webhooks", `{"url": "not a url"}`, http.StatusBadRequest},
+		{http.MethodPost, "/webhooks", `{` + hook + `, "events": ["book.read"]}`, http.StatusBadRequest},
+		{http.MethodPost, "/webhooks", `{` + hook + `, "secret": "short"}`, http.StatusBadRequest},
+		{http.MethodPost, "/webhooks", `{"events": ["book.created"]}`, http.StatusBadRequest},
+
+		{http.MethodGet, "/activity?method=put&path=/api/v1/books&from=2024-01-01T00:00:00Z&cursor=1700000000000-0", "", http.StatusOK},
+		{http.MethodGet, "/activity?from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z", "", http.StatusBadRequest},
+		{http.MethodGet, "/activity?cursor=nope", "", http.StatusBadRequest},
+		{http.MethodGet, "/activity?limit=1000", "", http.StatusBadRequest},
+		{http.MethodGet, "/activity?book_id=not-uuid", "", http.StatusBadRequest},
+		{http.MethodGet, "/activity?from=yesterday", "", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.target+" "+tc.body)
+	}
+}
@@ -0,0 +1,44 @@
+package test
+
+import (
+	"book_service/pkg/clients"
+	"book_service/pkg/consts"
+	"book_service/pkg/utils"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"type":"book.created"}`)
+	timestamp := time.Unix(1700000000, 0)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, utils.SignWebhookPayload("secret", timestamp, body))
+	assert.NotEqual(t, expected, utils.SignWebhookPayload("other", timestamp, body))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Hour
+
+	assert.Equal(t, 10*time.Second, utils.RetryBackoff(1, base, max))
+	assert.Equal(t, 20*time.Second, utils.RetryBackoff(2, base, max))
+	assert.Equal(t, 80*time.Second, utils.RetryBackoff(4, base, max))
+	assert.Equal(t, time.Hour, utils.RetryBackoff(20, base, max))
+}
+
+func TestWebhook_Accepts(t *testing.T) {
+	created := clients.BookEvent{Type: consts.EventBookCreated, Tenant: "acme"}
+
+	assert.True(t, clients.Webhook{}.Accepts(created))
+	assert.True(t, clients.Webhook{Events: []string{consts.EventBookCreated}, Tenant: "acme"}.Accepts(created))
+	assert.False(t, clients.Webhook{Events: []string{consts.EventBookDeleted}}.Accepts(created))
+	assert.False(t, clients.Webhook{Tenant: "globex"}.Accepts(created))
+}